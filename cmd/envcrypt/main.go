@@ -0,0 +1,204 @@
+// Command envcrypt encrypts and decrypts configuration values for envutils.WithDecryption,
+// and rotates the key of the encrypted values of files such as .env files.
+//
+// Usage:
+//
+//	envcrypt genkey
+//	envcrypt encrypt [-key-file file] [value]
+//	envcrypt decrypt [-key-file file] [value]
+//	envcrypt rotate [-key-file file] -old-key-file file [files...]
+//
+// The key is read from the -key-file file, or else from the ENVUTILS_KEY variable or the file named by
+// ENVUTILS_KEY_FILE. Values are read from the standard input when not given as argument. rotate re-encrypts
+// every enc:v1: value of the files in place with the new key, or of the standard input to the standard output
+// when no file is given; the old key can also be given with ENVUTILS_OLD_KEY or ENVUTILS_OLD_KEY_FILE.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Sectoid-Systems/sectoid-go-kit/envutils"
+)
+
+// oldKeyVar is the variable holding the key to rotate from.
+const oldKeyVar = "ENVUTILS_OLD_KEY"
+
+// encryptedValue matches the encrypted values of a file.
+var encryptedValue = regexp.MustCompile(regexp.QuoteMeta(envutils.EncryptedPrefix) + `[A-Za-z0-9+/]+=*`)
+
+const usage = `usage:
+  envcrypt genkey
+  envcrypt encrypt [-key-file file] [value]
+  envcrypt decrypt [-key-file file] [value]
+  envcrypt rotate [-key-file file] -old-key-file file [files...]
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command and returns its exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	cmd, args := args[0], args[1:]
+	flags := flag.NewFlagSet("envcrypt "+cmd, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	keyFile := flags.String("key-file", "", "file holding the base64 encoded key")
+	oldKeyFile := flags.String("old-key-file", "", "file holding the base64 encoded key to rotate from")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var err error
+	switch cmd {
+	case "genkey":
+		err = genKey(stdout)
+	case "encrypt", "decrypt":
+		err = transform(cmd, *keyFile, flags.Args(), stdin, stdout)
+	case "rotate":
+		err = rotate(*keyFile, *oldKeyFile, flags.Args(), stdin, stdout)
+	default:
+		fmt.Fprintf(stderr, "envcrypt: unknown command %q\n%s", cmd, usage)
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "envcrypt: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func genKey(stdout io.Writer) error {
+	key, err := envutils.GenerateKey()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, envutils.EncodeKey(key))
+	return err
+}
+
+// transform encrypts or decrypts the value given as argument or on the standard input.
+func transform(cmd, keyFile string, args []string, stdin io.Reader, stdout io.Writer) error {
+	key, err := loadKey(keyFile, envutils.DefaultKeyVar)
+	if err != nil {
+		return err
+	}
+	c, err := envutils.NewCipher(key)
+	if err != nil {
+		return err
+	}
+
+	var value string
+	switch len(args) {
+	case 0:
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		value = strings.TrimRight(string(data), "\r\n")
+	case 1:
+		value = args[0]
+	default:
+		return errors.New("expected a single value")
+	}
+
+	var out string
+	if cmd == "encrypt" {
+		out, err = c.Encrypt(value)
+	} else {
+		out, err = c.Decrypt(strings.TrimSpace(value))
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, out)
+	return err
+}
+
+// rotate re-encrypts the encrypted values of the files, or of the standard input, with the new key.
+func rotate(keyFile, oldKeyFile string, files []string, stdin io.Reader, stdout io.Writer) error {
+	key, err := loadKey(keyFile, envutils.DefaultKeyVar)
+	if err != nil {
+		return err
+	}
+	oldKey, err := loadKey(oldKeyFile, oldKeyVar)
+	if err != nil {
+		return err
+	}
+	c, err := envutils.NewCipher(key, oldKey)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		out, err := reencrypt(c, data)
+		if err != nil {
+			return err
+		}
+		_, err = stdout.Write(out)
+		return err
+	}
+
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		out, err := reencrypt(c, data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f, err)
+		}
+		fi, err := os.Stat(f)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(f, out, fi.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reencrypt re-encrypts every encrypted value of data. Nothing is returned if any of them cannot be decrypted.
+func reencrypt(c *envutils.Cipher, data []byte) ([]byte, error) {
+	var errs []error
+	out := encryptedValue.ReplaceAllStringFunc(string(data), func(v string) string {
+		rotated, err := c.Reencrypt(v)
+		if err != nil {
+			errs = append(errs, err)
+			return v
+		}
+		return rotated
+	})
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%d value(s) cannot be decrypted with the old or new key: %w", len(errs), errs[0])
+	}
+	return []byte(out), nil
+}
+
+// loadKey reads the key from the file if given, or else with envutils.LoadKey from the variable.
+func loadKey(file, variable string) ([]byte, error) {
+	if file == "" {
+		return envutils.LoadKey(variable)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return envutils.ParseKey(string(data))
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Sectoid-Systems/sectoid-go-kit/envutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKey generates a key, writes it to a temporary file and returns the file and a Cipher using the key.
+func writeKey(t *testing.T) (string, *envutils.Cipher) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, run([]string{"genkey"}, nil, &stdout, &stderr), stderr.String())
+
+	path := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(path, stdout.Bytes(), 0o600))
+
+	key, err := envutils.ParseKey(stdout.String())
+	require.NoError(t, err)
+	c, err := envutils.NewCipher(key)
+	require.NoError(t, err)
+	return path, c
+}
+
+func TestRun_EncryptDecrypt(t *testing.T) {
+	keyFile, c := writeKey(t)
+	var stdout, stderr bytes.Buffer
+
+	require.Equal(t, 0, run([]string{"encrypt", "-key-file", keyFile, "s3cr3t"}, nil, &stdout, &stderr), stderr.String())
+	enc := strings.TrimSpace(stdout.String())
+	assert.True(t, envutils.IsEncrypted(enc))
+	dec, err := c.Decrypt(enc)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", dec)
+
+	stdout.Reset()
+	require.Equal(t, 0, run([]string{"decrypt", "-key-file", keyFile}, strings.NewReader(enc+"\n"), &stdout, &stderr), stderr.String())
+	assert.Equal(t, "s3cr3t\n", stdout.String())
+}
+
+func TestRun_KeyFromEnv(t *testing.T) {
+	keyFile, c := writeKey(t)
+	t.Setenv(envutils.DefaultKeyVar+envutils.FileSuffix, keyFile)
+	var stdout, stderr bytes.Buffer
+
+	require.Equal(t, 0, run([]string{"encrypt"}, strings.NewReader("from stdin\n"), &stdout, &stderr), stderr.String())
+
+	dec, err := c.Decrypt(strings.TrimSpace(stdout.String()))
+	require.NoError(t, err)
+	assert.Equal(t, "from stdin", dec)
+}
+
+func TestRun_Rotate(t *testing.T) {
+	oldKeyFile, old := writeKey(t)
+	newKeyFile, c := writeKey(t)
+
+	password, err := old.Encrypt("s3cr3t")
+	require.NoError(t, err)
+	token, err := old.Encrypt("t0k3n")
+	require.NoError(t, err)
+
+	env := filepath.Join(t.TempDir(), ".env")
+	content := "# secrets\nPASSWORD=" + password + "\nTOKEN=\"" + token + "\"\nPLAIN=value\n"
+	require.NoError(t, os.WriteFile(env, []byte(content), 0o600))
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, run([]string{"rotate", "-key-file", newKeyFile, "-old-key-file", oldKeyFile, env}, nil, &stdout, &stderr), stderr.String())
+
+	vars, err := envutils.ReadDotEnv(env)
+	require.NoError(t, err)
+	assert.Equal(t, "value", vars["PLAIN"])
+	for k, want := range map[string]string{"PASSWORD": "s3cr3t", "TOKEN": "t0k3n"} {
+		_, err := old.Decrypt(vars[k])
+		assert.ErrorIs(t, err, envutils.ErrDecrypt, k)
+		got, err := c.Decrypt(vars[k])
+		require.NoError(t, err, k)
+		assert.Equal(t, want, got, k)
+	}
+
+	fi, err := os.Stat(env)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+}
+
+func TestRun_RotateUndecryptable(t *testing.T) {
+	oldKeyFile, _ := writeKey(t)
+	newKeyFile, _ := writeKey(t)
+	_, other := writeKey(t)
+	enc, err := other.Encrypt("s3cr3t")
+	require.NoError(t, err)
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"rotate", "-key-file", newKeyFile, "-old-key-file", oldKeyFile}, strings.NewReader("A="+enc+"\n"), &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Empty(t, stdout.String())
+	assert.Contains(t, stderr.String(), "1 value(s) cannot be decrypted")
+}
+
+func TestRun_Errors(t *testing.T) {
+	keyFile, _ := writeKey(t)
+	var stdout, stderr bytes.Buffer
+
+	assert.Equal(t, 2, run(nil, nil, &stdout, &stderr))
+	assert.Equal(t, 2, run([]string{"unknown"}, nil, &stdout, &stderr))
+	assert.Equal(t, 2, run([]string{"encrypt", "-unknown"}, nil, &stdout, &stderr))
+	assert.Equal(t, 1, run([]string{"encrypt", "-key-file", keyFile, "a", "b"}, nil, &stdout, &stderr))
+	assert.Equal(t, 1, run([]string{"decrypt", "-key-file", keyFile, "enc:v1:AAAA"}, nil, &stdout, &stderr))
+	assert.Equal(t, 1, run([]string{"encrypt", "-key-file", filepath.Join(t.TempDir(), "missing"), "a"}, nil, &stdout, &stderr))
+	assert.Equal(t, 1, run([]string{"rotate", "-key-file", keyFile}, nil, &stdout, &stderr), "the old key is required")
+}
@@ -0,0 +1,134 @@
+// Command envdoc generates the documentation of the environment variables read by envutils.Load,
+// from the struct tags of Go sources.
+//
+// Usage:
+//
+//	envdoc [flags] [paths...]
+//
+// Paths are Go files or directories, optionally followed by /... to include subdirectories,
+// and default to the current directory. Descriptions come from the desc tag, or the field comment:
+//
+//	type Config struct {
+//		// Port is the HTTP port.
+//		Port int    `env:"PORT" default:"8080"`
+//		DSN  string `env:"DSN" required:"true" secret:"true" desc:"Database connection string"`
+//	}
+//
+// The flags are:
+//
+//	-format markdown|json  Output format (default markdown).
+//	-out file              Write to file instead of the standard output. For Markdown files containing
+//	                       <!-- envdoc:begin --> and <!-- envdoc:end --> markers, only the content between
+//	                       them is replaced. Other existing Markdown files are only overwritten if they
+//	                       were generated by envdoc.
+//	-check                 Do not write, exit with status 1 if the -out file is not up to date.
+//	-type names            Comma-separated struct names to document. By default, every struct reading
+//	                       variables that is not nested in another one.
+//	-prefix prefix         Prefix prepended to every variable, as with envutils.WithPrefix.
+//
+// To keep a README up to date, add to one of the documented packages:
+//
+//	//go:generate go run github.com/Sectoid-Systems/sectoid-go-kit/cmd/envdoc -out README.md
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command and returns its exit status.
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("envdoc", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "markdown", "output format: markdown or json")
+	out := flags.String("out", "", "output file, standard output if empty")
+	check := flags.Bool("check", false, "exit with status 1 if the output file is not up to date")
+	typeNames := flags.String("type", "", "comma-separated struct names to document")
+	prefix := flags.String("prefix", "", "prefix prepended to every variable")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *check && *out == "" {
+		fmt.Fprintln(stderr, "envdoc: -check requires -out")
+		return 2
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	var types []string
+	if *typeNames != "" {
+		types = strings.Split(*typeNames, ",")
+	}
+
+	generated, err := generate(paths, types, *prefix, *format)
+	if err != nil {
+		fmt.Fprintf(stderr, "envdoc: %v\n", err)
+		return 1
+	}
+
+	if *out == "" {
+		_, _ = stdout.Write(generated)
+		return 0
+	}
+
+	existing, err := os.ReadFile(*out)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintf(stderr, "envdoc: %v\n", err)
+		return 1
+	}
+	content := generated
+	if *format == "markdown" {
+		if content, err = splice(existing, generated); err != nil {
+			fmt.Fprintf(stderr, "envdoc: %s: %v\n", *out, err)
+			return 1
+		}
+	}
+
+	if *check {
+		if !bytes.Equal(existing, content) {
+			fmt.Fprintf(stderr, "envdoc: %s is out of date, run envdoc to update it\n", *out)
+			return 1
+		}
+		return 0
+	}
+
+	if err := os.WriteFile(*out, content, 0o644); err != nil {
+		fmt.Fprintf(stderr, "envdoc: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// generate parses the sources and renders their documentation.
+func generate(paths, types []string, prefix, format string) ([]byte, error) {
+	set, err := parseSources(paths)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := set.document(types, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case "markdown":
+		return renderMarkdown(docs), nil
+	case "json":
+		return renderJSON(docs)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
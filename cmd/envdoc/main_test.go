@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const configSource = "package app\n" +
+	"\n" +
+	"type DBConfig struct {\n" +
+	"\tHost string `env:\"HOST\" default:\"localhost\"`\n" +
+	"\tPort int    `env:\"PORT\" default:\"5432\"`\n" +
+	"}\n" +
+	"\n" +
+	"type Config struct {\n" +
+	"\t// Port is the HTTP port.\n" +
+	"\tPort     int               `env:\"PORT\" default:\"8080\"`\n" +
+	"\tDSN      string            `env:\"DSN\" required:\"true\" secret:\"true\" desc:\"Database connection string\"`\n" +
+	"\tTimeout  *time.Duration    `env:\"TIMEOUT\"` // Request timeout.\n" +
+	"\tLabels   map[string]string `env:\"LABELS\" default:\"a=1|b=2\"`\n" +
+	"\tDB       DBConfig          `envPrefix:\"DB_\"`\n" +
+	"\tReplica  *DBConfig         `envPrefix:\"REPLICA_\"`\n" +
+	"\tinternal string            `env:\"INTERNAL\"`\n" +
+	"\tName     string\n" +
+	"}\n" +
+	"\n" +
+	"type unrelated struct{ X int }\n"
+
+// writeSources writes Go files into a temporary directory and returns it.
+func writeSources(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestDocument(t *testing.T) {
+	dir := writeSources(t, map[string]string{"config.go": configSource})
+	set, err := parseSources([]string{dir})
+	require.NoError(t, err)
+
+	docs, err := set.document(nil, "APP_")
+
+	require.NoError(t, err)
+	require.Len(t, docs, 1, "nested and untagged structs are not roots")
+	assert.Equal(t, "app", docs[0].Package)
+	assert.Equal(t, "Config", docs[0].Name)
+	assert.Equal(t, []Variable{
+		{Name: "APP_PORT", Field: "Port", Type: "int", Default: "8080", HasDefault: true, Description: "Port is the HTTP port."},
+		{Name: "APP_DSN", Field: "DSN", Type: "string", Required: true, Secret: true, Description: "Database connection string"},
+		{Name: "APP_TIMEOUT", Field: "Timeout", Type: "time.Duration", Description: "Request timeout."},
+		{Name: "APP_LABELS", Field: "Labels", Type: "map[string]string", Default: "a=1|b=2", HasDefault: true},
+		{Name: "APP_DB_HOST", Field: "DB.Host", Type: "string", Default: "localhost", HasDefault: true},
+		{Name: "APP_DB_PORT", Field: "DB.Port", Type: "int", Default: "5432", HasDefault: true},
+		{Name: "APP_REPLICA_HOST", Field: "Replica.Host", Type: "string", Default: "localhost", HasDefault: true},
+		{Name: "APP_REPLICA_PORT", Field: "Replica.Port", Type: "int", Default: "5432", HasDefault: true},
+	}, docs[0].Variables)
+}
+
+func TestDocument_Types(t *testing.T) {
+	dir := writeSources(t, map[string]string{"config.go": configSource})
+	set, err := parseSources([]string{dir})
+	require.NoError(t, err)
+
+	docs, err := set.document([]string{"DBConfig"}, "")
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "DBConfig", docs[0].Name)
+	assert.Len(t, docs[0].Variables, 2)
+
+	_, err = set.document([]string{"Missing"}, "")
+	assert.EqualError(t, err, "struct Missing not found")
+}
+
+func TestDocument_Recursive(t *testing.T) {
+	dir := writeSources(t, map[string]string{"config.go": "package app\n\n" +
+		"type Root struct{ Node Node }\n" +
+		"type Node struct {\n\tName string `env:\"NAME\"`\n\tNext *Node `envPrefix:\"NEXT_\"`\n}\n"})
+	set, err := parseSources([]string{dir})
+	require.NoError(t, err)
+
+	_, err = set.document([]string{"Root"}, "")
+	assert.EqualError(t, err, "recursive struct Node")
+}
+
+func TestParseSources_Paths(t *testing.T) {
+	dir := writeSources(t, map[string]string{
+		"a.go":            "package app\ntype A struct{ X string `env:\"A\"` }\n",
+		"a_test.go":       "package app\ntype T struct{ X string `env:\"T\"` }\n",
+		"sub/b.go":        "package sub\ntype B struct{ X string `env:\"B\"` }\n",
+		"testdata/c.go":   "package c\ntype C struct{ X string `env:\"C\"` }\n",
+		".hidden/d.go":    "package d\ntype D struct{ X string `env:\"D\"` }\n",
+		"sub/deeper/e.go": "package deeper\ntype E struct{ X string `env:\"E\"` }\n",
+	})
+
+	names := func(paths ...string) []string {
+		set, err := parseSources(paths)
+		require.NoError(t, err)
+		docs, err := set.document(nil, "")
+		require.NoError(t, err)
+		var names []string
+		for _, d := range docs {
+			names = append(names, d.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"A"}, names(dir))
+	assert.Equal(t, []string{"A", "B", "E"}, names(dir+"/..."))
+	assert.Equal(t, []string{"B"}, names(filepath.Join(dir, "sub", "b.go")))
+
+	_, err := parseSources([]string{filepath.Join(dir, "missing")})
+	assert.Error(t, err)
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	docs := []Struct{{Package: "app", Name: "Config", Variables: []Variable{
+		{Name: "PORT", Type: "int", Default: "8080", HasDefault: true, Description: "HTTP port"},
+		{Name: "DSN", Type: "string", Required: true, Secret: true, Description: "Database | primary"},
+		{Name: "EMPTY", Type: "string", Default: "", HasDefault: true},
+	}}}
+
+	expected := "<!-- Code generated by envdoc. DO NOT EDIT. -->\n" +
+		"\n" +
+		"| Variable | Type | Default | Required | Description |\n" +
+		"|----------|------|---------|----------|-------------|\n" +
+		"| `PORT` | `int` | `8080` | no | HTTP port |\n" +
+		"| `DSN` | `string` |  | yes | Secret. Database \\| primary |\n" +
+		"| `EMPTY` | `string` | `` | no |  |\n"
+	assert.Equal(t, expected, string(renderMarkdown(docs)))
+
+	docs = append(docs, Struct{Package: "worker", Name: "Config"})
+	assert.Contains(t, string(renderMarkdown(docs)), "### app.Config\n\n| Variable")
+	assert.Contains(t, string(renderMarkdown(docs)), "### worker.Config\n")
+}
+
+func TestRenderJSON(t *testing.T) {
+	out, err := renderJSON(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[]\n", string(out))
+
+	docs := []Struct{{Package: "app", Name: "Config", Variables: []Variable{{Name: "PORT", Field: "Port", Type: "int"}}}}
+	out, err = renderJSON(docs)
+	require.NoError(t, err)
+
+	var decoded []Struct
+	require.NoError(t, json.Unmarshal(out, &decoded))
+	assert.Equal(t, docs, decoded)
+}
+
+func TestSplice(t *testing.T) {
+	generated := []byte("table\n")
+
+	out, err := splice(nil, generated)
+	require.NoError(t, err)
+	assert.Equal(t, "table\n", string(out))
+
+	out, err = splice([]byte(generatedHeader+"old table\n"), generated)
+	require.NoError(t, err)
+	assert.Equal(t, "table\n", string(out))
+
+	_, err = splice([]byte("# Hand-written README\n"), generated)
+	assert.ErrorContains(t, err, "no <!-- envdoc:begin --> and <!-- envdoc:end --> markers")
+
+	out, err = splice([]byte("# App\n<!-- envdoc:begin -->\nold\n<!-- envdoc:end -->\nfooter\n"), generated)
+	require.NoError(t, err)
+	assert.Equal(t, "# App\n<!-- envdoc:begin -->\ntable\n<!-- envdoc:end -->\nfooter\n", string(out))
+
+	_, err = splice([]byte("<!-- envdoc:end --><!-- envdoc:begin -->"), generated)
+	assert.Error(t, err)
+}
+
+func TestRun(t *testing.T) {
+	dir := writeSources(t, map[string]string{"config.go": configSource})
+	readme := filepath.Join(dir, "README.md")
+	require.NoError(t, os.WriteFile(readme, []byte("# App\n\n<!-- envdoc:begin -->\n<!-- envdoc:end -->\n"), 0o644))
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, run([]string{"-check", "-out", readme, dir}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "is out of date")
+
+	stderr.Reset()
+	require.Equal(t, 0, run([]string{"-out", readme, dir}, &stdout, &stderr), stderr.String())
+	content, err := os.ReadFile(readme)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "# App\n\n<!-- envdoc:begin -->\n<!-- Code generated by envdoc")
+	assert.Contains(t, string(content), "| `REPLICA_PORT` | `int` | `5432` | no |  |\n<!-- envdoc:end -->\n")
+
+	assert.Equal(t, 0, run([]string{"-check", "-out", readme, dir}, &stdout, &stderr), stderr.String())
+
+	require.Equal(t, 0, run([]string{"-format", "json", dir}, &stdout, &stderr))
+	var docs []Struct
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &docs))
+	assert.Equal(t, "Config", docs[0].Name)
+}
+
+func TestRun_Errors(t *testing.T) {
+	dir := writeSources(t, map[string]string{"config.go": configSource})
+	var stdout, stderr bytes.Buffer
+
+	assert.Equal(t, 2, run([]string{"-check", dir}, &stdout, &stderr))
+	assert.Equal(t, 2, run([]string{"-unknown"}, &stdout, &stderr))
+	assert.Equal(t, 1, run([]string{"-format", "yaml", dir}, &stdout, &stderr))
+	assert.Equal(t, 1, run([]string{"-type", "Missing", dir}, &stdout, &stderr))
+	assert.Equal(t, 1, run([]string{filepath.Join(dir, "missing")}, &stdout, &stderr))
+
+	notes := filepath.Join(dir, "NOTES.md")
+	require.NoError(t, os.WriteFile(notes, []byte("# Notes\n"), 0o644))
+	assert.Equal(t, 1, run([]string{"-out", notes, dir}, &stdout, &stderr))
+	content, err := os.ReadFile(notes)
+	require.NoError(t, err)
+	assert.Equal(t, "# Notes\n", string(content), "hand-written files are not overwritten")
+}
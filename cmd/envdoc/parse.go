@@ -0,0 +1,313 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Variable documents an environment variable read by envutils.Load.
+type Variable struct {
+	Name        string `json:"name"`
+	Field       string `json:"field"`
+	Type        string `json:"type"`
+	Default     string `json:"default,omitempty"`
+	HasDefault  bool   `json:"hasDefault"`
+	Required    bool   `json:"required"`
+	Secret      bool   `json:"secret"`
+	Description string `json:"description,omitempty"`
+}
+
+// Struct documents the variables of a configuration struct.
+type Struct struct {
+	Package   string     `json:"package"`
+	Name      string     `json:"name"`
+	Variables []Variable `json:"variables"`
+}
+
+// structKey identifies a struct type declared in a package directory.
+type structKey struct {
+	dir  string
+	name string
+}
+
+type structDecl struct {
+	pkg  string
+	node *ast.StructType
+}
+
+// sourceSet holds the struct declarations of the parsed sources.
+type sourceSet struct {
+	structs map[structKey]structDecl
+	order   []structKey
+}
+
+// parseSources parses the Go files of the given paths: files, directories, or directories followed by /...
+// to include their subdirectories. Test files, testdata, vendor and hidden directories are skipped.
+func parseSources(paths []string) (*sourceSet, error) {
+	set := &sourceSet{structs: make(map[structKey]structDecl)}
+	fset := token.NewFileSet()
+
+	for _, p := range paths {
+		files, err := goFiles(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			file, err := parser.ParseFile(fset, f, nil, parser.ParseComments)
+			if err != nil {
+				return nil, err
+			}
+			set.add(filepath.Dir(f), file)
+		}
+	}
+	return set, nil
+}
+
+func goFiles(path string) ([]string, error) {
+	recursive := false
+	if rest, ok := strings.CutSuffix(path, "/..."); ok {
+		path, recursive = rest, true
+		if path == "" {
+			path = "."
+		}
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p == path {
+				return nil
+			}
+			name := d.Name()
+			if !recursive || name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(p, ".go") && !strings.HasSuffix(p, "_test.go") {
+			files = append(files, p)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+func (s *sourceSet) add(dir string, file *ast.File) {
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			key := structKey{dir: dir, name: ts.Name.Name}
+			s.structs[key] = structDecl{pkg: file.Name.Name, node: st}
+			s.order = append(s.order, key)
+		}
+	}
+}
+
+// document returns the documentation of the root structs: the given types if any, otherwise every struct
+// reading variables that is not nested in another one.
+func (s *sourceSet) document(types []string, prefix string) ([]Struct, error) {
+	var roots []structKey
+	if len(types) > 0 {
+		for _, name := range types {
+			found := false
+			for _, key := range s.order {
+				if key.name == name {
+					roots = append(roots, key)
+					found = true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("struct %s not found", name)
+			}
+		}
+	} else {
+		nested := make(map[structKey]bool)
+		for _, key := range s.order {
+			for _, f := range s.structs[key].node.Fields.List {
+				if _, hasEnv := lookupTag(f, "env"); hasEnv {
+					continue
+				}
+				if n, ok := s.nested(key.dir, f.Type); ok {
+					nested[n] = true
+				}
+			}
+		}
+		for _, key := range s.order {
+			if !nested[key] {
+				roots = append(roots, key)
+			}
+		}
+	}
+
+	var docs []Struct
+	for _, key := range roots {
+		vars, err := s.variables(key, "", prefix, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(vars) == 0 {
+			continue
+		}
+		docs = append(docs, Struct{Package: s.structs[key].pkg, Name: key.name, Variables: vars})
+	}
+	return docs, nil
+}
+
+// variables walks a struct like envutils.Load does, following nested structs declared in the parsed sources.
+func (s *sourceSet) variables(key structKey, path, prefix string, visiting []structKey) ([]Variable, error) {
+	for _, v := range visiting {
+		if v == key {
+			return nil, fmt.Errorf("recursive struct %s", key.name)
+		}
+	}
+	visiting = append(visiting, key)
+
+	var vars []Variable
+	for _, f := range s.structs[key].node.Fields.List {
+		names := fieldNames(f)
+		env, hasEnv := lookupTag(f, "env")
+
+		for _, name := range names {
+			if !ast.IsExported(name) {
+				continue
+			}
+
+			if !hasEnv {
+				nested, ok := s.nested(key.dir, f.Type)
+				if !ok {
+					continue
+				}
+				envPrefix, _ := lookupTag(f, "envPrefix")
+				sub, err := s.variables(nested, path+name+".", prefix+envPrefix, visiting)
+				if err != nil {
+					return nil, err
+				}
+				vars = append(vars, sub...)
+				continue
+			}
+
+			def, hasDefault := lookupTag(f, "default")
+			required, _ := lookupTag(f, "required")
+			secret, _ := lookupTag(f, "secret")
+			desc, hasDesc := lookupTag(f, "desc")
+			if !hasDesc {
+				desc = commentText(f)
+			}
+
+			vars = append(vars, Variable{
+				Name:        prefix + env,
+				Field:       path + name,
+				Type:        typeString(f.Type),
+				Default:     def,
+				HasDefault:  hasDefault,
+				Required:    required == "true",
+				Secret:      secret == "true",
+				Description: desc,
+			})
+		}
+	}
+	return vars, nil
+}
+
+// nested resolves a struct or pointer-to-struct field type declared in the same directory.
+func (s *sourceSet) nested(dir string, expr ast.Expr) (structKey, bool) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return structKey{}, false
+	}
+	key := structKey{dir: dir, name: ident.Name}
+	_, ok = s.structs[key]
+	return key, ok
+}
+
+func fieldNames(f *ast.Field) []string {
+	if len(f.Names) > 0 {
+		names := make([]string, len(f.Names))
+		for i, n := range f.Names {
+			names[i] = n.Name
+		}
+		return names
+	}
+
+	// Embedded field, named after its type.
+	expr := f.Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return []string{t.Name}
+	case *ast.SelectorExpr:
+		return []string{t.Sel.Name}
+	default:
+		return nil
+	}
+}
+
+func lookupTag(f *ast.Field, name string) (string, bool) {
+	if f.Tag == nil {
+		return "", false
+	}
+	tag, err := strconv.Unquote(f.Tag.Value)
+	if err != nil {
+		return "", false
+	}
+	return reflect.StructTag(tag).Lookup(name)
+}
+
+// typeString formats a field type the way envutils reports it, without pointer indirection.
+func typeString(expr ast.Expr) string {
+	for {
+		star, ok := expr.(*ast.StarExpr)
+		if !ok {
+			break
+		}
+		expr = star.X
+	}
+	return types.ExprString(expr)
+}
+
+// commentText returns the doc comment of a field, or its line comment, on a single line.
+func commentText(f *ast.Field) string {
+	group := f.Doc
+	if group == nil {
+		group = f.Comment
+	}
+	if group == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(group.Text()), " ")
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Markers delimiting the generated section of a Markdown file that also holds hand-written content.
+const (
+	beginMarker = "<!-- envdoc:begin -->"
+	endMarker   = "<!-- envdoc:end -->"
+)
+
+// generatedHeader starts the Markdown rendered by envdoc.
+const generatedHeader = "<!-- Code generated by envdoc. DO NOT EDIT. -->\n"
+
+// renderMarkdown renders a table per struct, with a heading when there are several.
+func renderMarkdown(docs []Struct) []byte {
+	var b bytes.Buffer
+	b.WriteString(generatedHeader)
+
+	for _, doc := range docs {
+		b.WriteString("\n")
+		if len(docs) > 1 {
+			fmt.Fprintf(&b, "### %s.%s\n\n", doc.Package, doc.Name)
+		}
+		b.WriteString("| Variable | Type | Default | Required | Description |\n")
+		b.WriteString("|----------|------|---------|----------|-------------|\n")
+		for _, v := range doc.Variables {
+			def := ""
+			if v.HasDefault {
+				def = "`" + v.Default + "`"
+			}
+			required := "no"
+			if v.Required {
+				required = "yes"
+			}
+			desc := v.Description
+			if v.Secret {
+				desc = strings.TrimSpace("Secret. " + desc)
+			}
+			fmt.Fprintf(&b, "| `%s` | `%s` | %s | %s | %s |\n",
+				v.Name, v.Type, escapeCell(def), required, escapeCell(desc))
+		}
+	}
+	return b.Bytes()
+}
+
+func escapeCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// renderJSON renders the documentation as an indented JSON array.
+func renderJSON(docs []Struct) ([]byte, error) {
+	if docs == nil {
+		docs = []Struct{}
+	}
+	out, err := json.MarshalIndent(docs, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// splice replaces the content between the envdoc markers of existing with generated.
+// If existing has no markers, generated replaces it only if it is empty or was generated by envdoc, so that
+// hand-written files are never overwritten.
+func splice(existing, generated []byte) ([]byte, error) {
+	begin := bytes.Index(existing, []byte(beginMarker))
+	end := bytes.Index(existing, []byte(endMarker))
+	switch {
+	case begin < 0 && end < 0:
+		if len(bytes.TrimSpace(existing)) == 0 || bytes.HasPrefix(existing, []byte(generatedHeader)) {
+			return generated, nil
+		}
+		return nil, fmt.Errorf("no %s and %s markers, add them where the documentation goes", beginMarker, endMarker)
+	case begin < 0 || end < begin:
+		return nil, fmt.Errorf("mismatched %s and %s markers", beginMarker, endMarker)
+	}
+
+	var b bytes.Buffer
+	b.Write(existing[:begin+len(beginMarker)])
+	b.WriteString("\n")
+	b.Write(generated)
+	b.Write(existing[end:])
+	return b.Bytes(), nil
+}
@@ -0,0 +1,154 @@
+package ctxutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// CloseFunc adapts a plain function to the Closeable interface.
+type CloseFunc func() error
+
+// Close calls f.
+func (f CloseFunc) Close() error {
+	return f()
+}
+
+// CloseError identifies the resource that failed to close.
+type CloseError struct {
+	Name string
+	Err  error
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("closing %s: %v", e.Name, e.Err)
+}
+
+func (e *CloseError) Unwrap() error {
+	return e.Err
+}
+
+type namedCloser struct {
+	name string
+	c    Closeable
+}
+
+// CloserGroupOption configures a CloserGroup.
+type CloserGroupOption func(*CloserGroup)
+
+// WithParallelClose closes all resources concurrently instead of in reverse registration order.
+func WithParallelClose() CloserGroupOption {
+	return func(g *CloserGroup) {
+		g.parallel = true
+	}
+}
+
+// CloserGroup collects resources and closes them in reverse registration order (LIFO).
+// Closing is idempotent: subsequent calls return the result of the first one.
+type CloserGroup struct {
+	mu       sync.Mutex
+	closers  []namedCloser
+	parallel bool
+	closed   bool
+	err      error
+}
+
+// NewCloserGroup creates an empty CloserGroup.
+func NewCloserGroup(opts ...CloserGroupOption) *CloserGroup {
+	g := &CloserGroup{}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Add registers a resource under the given name. Resources implementing CloseableWithCheck
+// are skipped when already closed. If the group is already closed, the resource is closed
+// immediately and its error is joined to the group result.
+func (g *CloserGroup) Add(name string, c Closeable) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	nc := namedCloser{name: name, c: c}
+	if g.closed {
+		g.err = errors.Join(g.err, closeOne(context.Background(), nc))
+		return
+	}
+	g.closers = append(g.closers, nc)
+}
+
+// AddFunc registers a plain close function under the given name.
+func (g *CloserGroup) AddFunc(name string, fn func() error) {
+	g.Add(name, CloseFunc(fn))
+}
+
+// IsClosed reports whether Close has been called.
+func (g *CloserGroup) IsClosed() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.closed
+}
+
+// Close closes every registered resource and returns the failures joined as *CloseError values.
+func (g *CloserGroup) Close() error {
+	return g.CloseContext(context.Background())
+}
+
+// CloseContext closes every registered resource, giving up on the ones still closing
+// when the context is done. Their failures are reported with the context error.
+func (g *CloserGroup) CloseContext(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return g.err
+	}
+	g.closed = true
+
+	errs := make([]error, len(g.closers))
+	if g.parallel {
+		var wg sync.WaitGroup
+		for i, nc := range g.closers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = closeOne(ctx, nc)
+			}()
+		}
+		wg.Wait()
+	} else {
+		for i := range g.closers {
+			errs[i] = closeOne(ctx, g.closers[len(g.closers)-1-i])
+		}
+	}
+
+	g.closers = nil
+	g.err = errors.Join(errs...)
+	return g.err
+}
+
+// closeOne closes a single resource, returning a *CloseError on failure or when the context is done first.
+func closeOne(ctx context.Context, nc namedCloser) error {
+	if cc, ok := nc.c.(CloseableWithCheck); ok && cc.IsClosed() {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return &CloseError{Name: nc.name, Err: err}
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- nc.c.Close()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return &CloseError{Name: nc.name, Err: err}
+		}
+		return nil
+	case <-ctx.Done():
+		return &CloseError{Name: nc.name, Err: ctx.Err()}
+	}
+}
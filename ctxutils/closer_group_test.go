@@ -0,0 +1,122 @@
+package ctxutils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloserGroup_CloseLIFO(t *testing.T) {
+	g := NewCloserGroup()
+
+	var order []string
+	for _, name := range []string{"db", "cache", "http"} {
+		g.AddFunc(name, func() error {
+			order = append(order, name)
+			return nil
+		})
+	}
+
+	assert.NoError(t, g.Close())
+	assert.Equal(t, []string{"http", "cache", "db"}, order)
+	assert.True(t, g.IsClosed())
+}
+
+func TestCloserGroup_SkipsClosedResources(t *testing.T) {
+	g := NewCloserGroup()
+	closed := &mockCloseable{closed: true, closeErr: errors.New("must not be called")}
+	open := &mockCloseable{}
+
+	g.Add("closed", closed)
+	g.Add("open", open)
+
+	assert.NoError(t, g.Close())
+	assert.True(t, open.closed)
+}
+
+func TestCloserGroup_AggregatesErrors(t *testing.T) {
+	g := NewCloserGroup()
+	dbErr := errors.New("db failure")
+	cacheErr := errors.New("cache failure")
+
+	g.Add("db", &mockCloseable{closeErr: dbErr})
+	g.AddFunc("cache", func() error { return cacheErr })
+	g.AddFunc("http", func() error { return nil })
+
+	err := g.Close()
+
+	assert.ErrorIs(t, err, dbErr)
+	assert.ErrorIs(t, err, cacheErr)
+
+	var ce *CloseError
+	assert.ErrorAs(t, err, &ce)
+	assert.Equal(t, "cache", ce.Name, "the first failure in closing order should be cache")
+	assert.Contains(t, err.Error(), "closing db: db failure")
+}
+
+func TestCloserGroup_Idempotent(t *testing.T) {
+	g := NewCloserGroup()
+	calls := 0
+	closeErr := errors.New("close error")
+	g.AddFunc("res", func() error {
+		calls++
+		return closeErr
+	})
+
+	first := g.Close()
+	second := g.Close()
+
+	assert.Equal(t, 1, calls)
+	assert.ErrorIs(t, first, closeErr)
+	assert.Equal(t, first, second)
+}
+
+func TestCloserGroup_AddAfterClose(t *testing.T) {
+	g := NewCloserGroup()
+	assert.NoError(t, g.Close())
+
+	late := &mockCloseable{}
+	g.Add("late", late)
+
+	assert.True(t, late.closed, "resources added after Close should be closed immediately")
+}
+
+func TestCloserGroup_ParallelWithDeadline(t *testing.T) {
+	g := NewCloserGroup(WithParallelClose())
+
+	var mu sync.Mutex
+	closed := map[string]bool{}
+	for _, name := range []string{"a", "b", "c"} {
+		g.AddFunc(name, func() error {
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			defer mu.Unlock()
+			closed[name] = true
+			return nil
+		})
+	}
+	g.AddFunc("stuck", func() error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := g.CloseContext(ctx)
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "closers should run concurrently and stop at the deadline")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	var ce *CloseError
+	if assert.ErrorAs(t, err, &ce) {
+		assert.Equal(t, "stuck", ce.Name)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, closed, 3)
+}
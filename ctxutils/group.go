@@ -0,0 +1,134 @@
+package ctxutils
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/Sectoid-Systems/sectoid-go-kit/misc"
+)
+
+// GroupOption configures a Group.
+type GroupOption func(*Group)
+
+// WithLimit bounds the number of goroutines running concurrently. Go blocks while the limit is reached.
+// A limit lower than 1 means no limit.
+func WithLimit(n int) GroupOption {
+	return func(g *Group) {
+		if n > 0 {
+			g.sem = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithCollectAll keeps running sibling goroutines after an error and makes Wait return every error joined,
+// instead of cancelling the group on the first one.
+func WithCollectAll() GroupOption {
+	return func(g *Group) {
+		g.collectAll = true
+	}
+}
+
+// WithGroupLogger sets the function used to report errors and recovered panics as they happen.
+func WithGroupLogger(lf misc.LoggerFunc) GroupOption {
+	return func(g *Group) {
+		g.lf = lf
+	}
+}
+
+// Group runs functions in goroutines with bounded concurrency, converting panics into *PanicError values.
+// By default the first error cancels the group context and is the one returned by Wait.
+type Group struct {
+	cancel     context.CancelCauseFunc
+	wg         sync.WaitGroup
+	sem        chan struct{}
+	collectAll bool
+	lf         misc.LoggerFunc
+
+	mu   sync.Mutex
+	errs []error
+}
+
+// NewGroup creates a Group and a derived context cancelled on the first error (unless WithCollectAll is set)
+// or when Wait returns.
+func NewGroup(ctx context.Context, opts ...GroupOption) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	g := &Group{cancel: cancel}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g, ctx
+}
+
+// Go runs fn in a new goroutine, blocking first while the concurrency limit is reached.
+func (g *Group) Go(fn func() error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.start(fn)
+}
+
+// TryGo runs fn in a new goroutine only if the concurrency limit is not reached, and reports whether it did.
+func (g *Group) TryGo(fn func() error) bool {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	g.start(fn)
+	return true
+}
+
+// start runs fn in a goroutine holding an already acquired concurrency slot.
+func (g *Group) start(fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.sem != nil {
+			defer func() { <-g.sem }()
+		}
+
+		if err := callSafely(fn); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+// Wait blocks until every goroutine has returned, then cancels the group context.
+// It returns the first error, or every error joined when WithCollectAll is set.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(context.Canceled)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.errs) == 0 {
+		return nil
+	}
+	if g.collectAll {
+		return errors.Join(g.errs...)
+	}
+	return g.errs[0]
+}
+
+func (g *Group) fail(err error) {
+	if g.lf != nil {
+		var pe *PanicError
+		if errors.As(err, &pe) {
+			g.lf("group: goroutine panicked: %v\n%s", pe.Value, pe.Stack)
+		} else {
+			g.lf("group: goroutine failed: %v", err)
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.errs = append(g.errs, err)
+	if !g.collectAll && len(g.errs) == 1 {
+		g.cancel(err)
+	}
+}
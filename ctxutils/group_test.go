@@ -0,0 +1,115 @@
+package ctxutils
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup_FirstErrorCancelsSiblings(t *testing.T) {
+	g, ctx := NewGroup(context.Background())
+	failure := errors.New("failure")
+
+	g.Go(func() error {
+		return failure
+	})
+	g.Go(func() error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return errors.New("sibling was not cancelled")
+		}
+	})
+
+	err := g.Wait()
+
+	assert.Equal(t, failure, err)
+	assert.Equal(t, failure, context.Cause(ctx))
+}
+
+func TestGroup_CollectAll(t *testing.T) {
+	g, ctx := NewGroup(context.Background(), WithCollectAll())
+	errA := errors.New("a")
+	errB := errors.New("b")
+
+	g.Go(func() error { return errA })
+	g.Go(func() error { return errB })
+	g.Go(func() error {
+		time.Sleep(20 * time.Millisecond)
+		return ctx.Err()
+	})
+
+	err := g.Wait()
+
+	assert.ErrorIs(t, err, errA)
+	assert.ErrorIs(t, err, errB)
+	assert.NotErrorIs(t, err, context.Canceled, "siblings must keep running when collecting all errors")
+}
+
+func TestGroup_Limit(t *testing.T) {
+	g, _ := NewGroup(context.Background(), WithLimit(2))
+
+	var running, peak atomic.Int32
+	for i := 0; i < 10; i++ {
+		g.Go(func() error {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+			return nil
+		})
+	}
+
+	assert.NoError(t, g.Wait())
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+}
+
+func TestGroup_TryGo(t *testing.T) {
+	g, _ := NewGroup(context.Background(), WithLimit(1))
+	release := make(chan struct{})
+
+	assert.True(t, g.TryGo(func() error {
+		<-release
+		return nil
+	}))
+	assert.False(t, g.TryGo(func() error { return nil }), "limit reached")
+
+	close(release)
+	assert.NoError(t, g.Wait())
+}
+
+func TestGroup_RecoversPanics(t *testing.T) {
+	lm := &logMock{}
+	g, _ := NewGroup(context.Background(), WithGroupLogger(lm.Logf))
+
+	g.Go(func() error {
+		panic("boom")
+	})
+
+	err := g.Wait()
+
+	var pe *PanicError
+	if assert.ErrorAs(t, err, &pe) {
+		assert.Equal(t, "boom", pe.Value)
+		assert.Contains(t, string(pe.Stack), "group_test.go")
+	}
+	assert.True(t, lm.Called)
+}
+
+func TestGroup_WaitCancelsContext(t *testing.T) {
+	g, ctx := NewGroup(context.Background())
+	g.Go(func() error { return nil })
+
+	assert.NoError(t, g.Wait())
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+}
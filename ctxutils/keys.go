@@ -0,0 +1,119 @@
+package ctxutils
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ContextKey is implemented by every *Key[T], allowing keys of different value types to be handled together.
+type ContextKey interface {
+	Name() string
+	value(ctx context.Context) (any, bool)
+	copyValue(dst, src context.Context) context.Context
+}
+
+var (
+	keysMu         sync.RWMutex
+	registeredKeys []ContextKey
+)
+
+// Key is a typed context key. Keys are compared by identity, so two keys with the same name never collide.
+type Key[T any] struct {
+	name string
+}
+
+// NewKey creates and registers a typed context key. The name is used by DumpKeys and in panic messages.
+// Keys are meant to be created once, as package-level variables.
+func NewKey[T any](name string) *Key[T] {
+	k := &Key[T]{name: name}
+
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	registeredKeys = append(registeredKeys, k)
+
+	return k
+}
+
+// Name returns the key name.
+func (k *Key[T]) Name() string {
+	return k.name
+}
+
+// String implements fmt.Stringer.
+func (k *Key[T]) String() string {
+	return "ctxutils.Key(" + k.name + ")"
+}
+
+// With returns a copy of ctx carrying v under this key.
+func (k *Key[T]) With(ctx context.Context, v T) context.Context {
+	return context.WithValue(ctx, k, v)
+}
+
+// From returns the value stored under this key and whether it was present.
+func (k *Key[T]) From(ctx context.Context) (T, bool) {
+	v, ok := ctx.Value(k).(T)
+	return v, ok
+}
+
+// FromWithDefault returns the value stored under this key, or defaultVal if it is not present.
+func (k *Key[T]) FromWithDefault(ctx context.Context, defaultVal T) T {
+	if v, ok := k.From(ctx); ok {
+		return v
+	}
+	return defaultVal
+}
+
+// MustFrom returns the value stored under this key and panics if it is not present.
+func (k *Key[T]) MustFrom(ctx context.Context) T {
+	v, ok := k.From(ctx)
+	if !ok {
+		panic(fmt.Sprintf("ctxutils: no value for context key %q", k.name))
+	}
+	return v
+}
+
+func (k *Key[T]) value(ctx context.Context) (any, bool) {
+	return k.From(ctx)
+}
+
+func (k *Key[T]) copyValue(dst, src context.Context) context.Context {
+	if v, ok := k.From(src); ok {
+		return k.With(dst, v)
+	}
+	return dst
+}
+
+// DumpKeys returns the values of every registered key present in the context, indexed by key name.
+// Keys sharing a name are suffixed with their rank in registration order, e.g. "tenant", "tenant#2",
+// so that none of them hides another.
+// It is intended for debugging and should not be logged when keys may hold sensitive values.
+func DumpKeys(ctx context.Context) map[string]any {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+
+	dump := make(map[string]any)
+	seen := make(map[string]int)
+	for _, k := range registeredKeys {
+		seen[k.Name()]++
+		if v, ok := k.value(ctx); ok {
+			name := k.Name()
+			if n := seen[name]; n > 1 {
+				name = fmt.Sprintf("%s#%d", name, n)
+			}
+			dump[name] = v
+		}
+	}
+	return dump
+}
+
+// Detach returns a background context carrying the values of the given keys from ctx.
+// The returned context is neither cancelled with ctx nor bound to its deadline, which makes it
+// suitable for asynchronous work that outlives a request.
+func Detach(ctx context.Context, keys ...ContextKey) context.Context {
+	detached := context.Background()
+	for _, k := range keys {
+		detached = k.copyValue(detached, ctx)
+	}
+	return detached
+}
@@ -0,0 +1,93 @@
+package ctxutils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type principal struct {
+	Subject string
+	Roles   []string
+}
+
+var (
+	tenantKey    = NewKey[string]("tenant_id")
+	requestIDKey = NewKey[string]("request_id")
+	principalKey = NewKey[*principal]("principal")
+)
+
+func TestKey_WithAndFrom(t *testing.T) {
+	ctx := tenantKey.With(context.Background(), "acme")
+
+	tenant, ok := tenantKey.From(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "acme", tenant)
+
+	_, ok = requestIDKey.From(ctx)
+	assert.False(t, ok, "keys with the same value type must not collide")
+
+	assert.Equal(t, "fallback", requestIDKey.FromWithDefault(ctx, "fallback"))
+}
+
+func TestKey_SameNameDoesNotCollide(t *testing.T) {
+	other := NewKey[string]("tenant_id")
+	ctx := tenantKey.With(context.Background(), "acme")
+
+	_, ok := other.From(ctx)
+	assert.False(t, ok)
+}
+
+func TestKey_MustFrom(t *testing.T) {
+	p := &principal{Subject: "user-1"}
+	ctx := principalKey.With(context.Background(), p)
+
+	assert.Same(t, p, principalKey.MustFrom(ctx))
+	assert.PanicsWithValue(t, `ctxutils: no value for context key "request_id"`, func() {
+		requestIDKey.MustFrom(ctx)
+	})
+}
+
+func TestDumpKeys(t *testing.T) {
+	ctx := tenantKey.With(context.Background(), "acme")
+	ctx = requestIDKey.With(ctx, "req-42")
+
+	dump := DumpKeys(ctx)
+
+	assert.Equal(t, "acme", dump["tenant_id"])
+	assert.Equal(t, "req-42", dump["request_id"])
+	assert.NotContains(t, dump, "principal")
+}
+
+func TestDumpKeys_SameName(t *testing.T) {
+	first := NewKey[string]("dump_same_name")
+	second := NewKey[int]("dump_same_name")
+	ctx := first.With(context.Background(), "a")
+	ctx = second.With(ctx, 2)
+
+	dump := DumpKeys(ctx)
+
+	assert.Equal(t, "a", dump["dump_same_name"])
+	assert.Equal(t, 2, dump["dump_same_name#2"])
+
+	dump = DumpKeys(second.With(context.Background(), 3))
+	assert.Equal(t, map[string]any{"dump_same_name#2": 3}, dump, "suffixes do not depend on the keys present")
+}
+
+func TestDetach(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	ctx = tenantKey.With(ctx, "acme")
+	ctx = requestIDKey.With(ctx, "req-42")
+	cancel()
+
+	detached := Detach(ctx, tenantKey, principalKey)
+
+	assert.NoError(t, detached.Err(), "detached context must not inherit cancellation")
+	_, hasDeadline := detached.Deadline()
+	assert.False(t, hasDeadline)
+	assert.Equal(t, "acme", tenantKey.MustFrom(detached))
+	_, ok := requestIDKey.From(detached)
+	assert.False(t, ok, "keys not listed must not be copied")
+}
@@ -0,0 +1,174 @@
+package ctxutils
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Sectoid-Systems/sectoid-go-kit/misc"
+)
+
+// Leak describes a tracked resource that was not closed.
+type Leak struct {
+	// Name is the name given to Track.
+	Name string
+	// Stack is the stack trace captured when the resource was tracked.
+	Stack string
+	// Collected is true when the tracked handle was garbage collected without being closed,
+	// and false when it is still reachable but open.
+	Collected bool
+}
+
+func (l Leak) String() string {
+	state := "still open"
+	if l.Collected {
+		state = "garbage collected without Close"
+	}
+	return fmt.Sprintf("resource %s %s, tracked at:\n%s", l.Name, state, l.Stack)
+}
+
+type trackedState struct {
+	id       uint64
+	name     string
+	stack    string
+	isClosed func() bool
+}
+
+// LeakTracker records where tracked resources were allocated and reports the ones never closed.
+// Tracking captures a stack trace per resource, so it is meant for tests and debugging builds.
+type LeakTracker struct {
+	mu        sync.Mutex
+	nextID    uint64
+	open      map[uint64]*trackedState
+	collected []Leak
+	lf        misc.LoggerFunc
+}
+
+// NewLeakTracker creates a LeakTracker reporting leaks through lf.
+func NewLeakTracker(lf misc.LoggerFunc) *LeakTracker {
+	return &LeakTracker{
+		open: make(map[uint64]*trackedState),
+		lf:   lf,
+	}
+}
+
+// Tracked wraps a resource registered in a LeakTracker. The wrapper must be used to close the resource,
+// and must be kept reachable for as long as the resource is in use.
+type Tracked[T CloseableWithCheck] struct {
+	Resource T
+	tracker  *LeakTracker
+	state    *trackedState
+}
+
+// Track registers a resource in the tracker and returns the handle to use in its place.
+// If the handle is garbage collected while the resource is open, a leak is reported immediately.
+func Track[T CloseableWithCheck](t *LeakTracker, name string, c T) *Tracked[T] {
+	t.mu.Lock()
+	t.nextID++
+	state := &trackedState{
+		id:       t.nextID,
+		name:     name,
+		stack:    string(debug.Stack()),
+		isClosed: c.IsClosed,
+	}
+	t.open[state.id] = state
+	t.mu.Unlock()
+
+	tr := &Tracked[T]{Resource: c, tracker: t, state: state}
+	runtime.SetFinalizer(tr, func(tr *Tracked[T]) {
+		tr.tracker.collect(tr.state)
+	})
+	return tr
+}
+
+// Close closes the resource and stops tracking it.
+func (tr *Tracked[T]) Close() error {
+	err := tr.Resource.Close()
+	tr.tracker.untrack(tr.state)
+	return err
+}
+
+// IsClosed reports whether the resource is closed.
+func (tr *Tracked[T]) IsClosed() bool {
+	return tr.Resource.IsClosed()
+}
+
+// Leaks returns the resources collected without being closed and the ones still open.
+// Resources closed without going through their Tracked handle are not reported.
+func (t *LeakTracker) Leaks() []Leak {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	leaks := append([]Leak(nil), t.collected...)
+
+	ids := make([]uint64, 0, len(t.open))
+	for id := range t.open {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		state := t.open[id]
+		if state.isClosed() {
+			delete(t.open, id)
+			continue
+		}
+		leaks = append(leaks, Leak{Name: state.name, Stack: state.stack})
+	}
+	return leaks
+}
+
+// Report logs every leak and returns how many were found.
+func (t *LeakTracker) Report() int {
+	leaks := t.Leaks()
+	for _, l := range leaks {
+		t.lf("leak detected: %s", l)
+	}
+	return len(leaks)
+}
+
+func (t *LeakTracker) untrack(state *trackedState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.open, state.id)
+}
+
+// collect is called by the finalizer of a Tracked handle.
+func (t *LeakTracker) collect(state *trackedState) {
+	t.mu.Lock()
+	_, tracked := t.open[state.id]
+	delete(t.open, state.id)
+	leaked := tracked && !state.isClosed()
+	if leaked {
+		t.collected = append(t.collected, Leak{Name: state.name, Stack: state.stack, Collected: true})
+	}
+	t.mu.Unlock()
+
+	if leaked {
+		t.lf("leak detected: %s", Leak{Name: state.name, Stack: state.stack, Collected: true})
+	}
+}
+
+// TestingT is the subset of testing.TB used by VerifyNoLeaks.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// VerifyNoLeaks runs the garbage collector so pending finalizers report collected handles,
+// then fails the test for every leaked resource. Call it at the end of a test, or with t.Cleanup.
+func VerifyNoLeaks(t TestingT, tracker *LeakTracker) {
+	t.Helper()
+
+	for i := 0; i < 3; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for _, l := range tracker.Leaks() {
+		t.Errorf("leak detected: %s", l)
+	}
+}
@@ -0,0 +1,91 @@
+package ctxutils
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeT is a TestingT recording reported errors.
+type fakeT struct {
+	errors []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestLeakTracker_ClosedResourcesAreNotReported(t *testing.T) {
+	tracker := NewLeakTracker(func(string, ...any) {})
+
+	res := Track(tracker, "db", &mockCloseable{})
+	assert.NoError(t, res.Close())
+	assert.True(t, res.IsClosed())
+
+	// Closing the underlying resource directly is detected through IsClosed.
+	direct := Track(tracker, "cache", &mockCloseable{})
+	assert.NoError(t, direct.Resource.Close())
+
+	assert.Empty(t, tracker.Leaks())
+	ft := &fakeT{}
+	VerifyNoLeaks(ft, tracker)
+	assert.Empty(t, ft.errors)
+}
+
+func TestLeakTracker_OpenResources(t *testing.T) {
+	lm := &logMock{}
+	tracker := NewLeakTracker(lm.Logf)
+
+	res := Track(tracker, "db", &mockCloseable{})
+
+	leaks := tracker.Leaks()
+	if assert.Len(t, leaks, 1) {
+		assert.Equal(t, "db", leaks[0].Name)
+		assert.False(t, leaks[0].Collected)
+		assert.Contains(t, leaks[0].Stack, "leak_test.go", "the stack should point at the allocation site")
+	}
+	assert.Equal(t, 1, tracker.Report())
+	assert.True(t, lm.Called)
+
+	runtime.KeepAlive(res)
+}
+
+func TestLeakTracker_CollectedWithoutClose(t *testing.T) {
+	tracker := NewLeakTracker(func(string, ...any) {})
+
+	func() {
+		_ = Track(tracker, "forgotten", &mockCloseable{})
+	}()
+
+	ft := &fakeT{}
+	VerifyNoLeaks(ft, tracker)
+
+	if assert.Len(t, ft.errors, 1) {
+		assert.Contains(t, ft.errors[0], "forgotten garbage collected without Close")
+	}
+}
+
+func TestWaitForShutdown_ReportsLeaks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	lm := &logMock{}
+	tracker := NewLeakTracker(lm.Logf)
+	res := Track(tracker, "db", &mockCloseable{})
+
+	WaitForShutdown(ctx, func() error { return nil },
+		WithLeakTracker(tracker),
+		WithSignalSource(&fakeSignals{}),
+		WithGracePeriod(time.Second),
+		WithLoggerFunc(func(string, ...any) {}),
+	)
+
+	assert.True(t, lm.Called, "the open resource should be reported at shutdown")
+	runtime.KeepAlive(res)
+}
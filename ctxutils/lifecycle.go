@@ -0,0 +1,242 @@
+package ctxutils
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Default lifecycle phases, listed in start order. Stop runs them in reverse,
+// so traffic stops first and observability (loggers, tracers) is flushed last.
+const (
+	PhaseObservability = "observability"
+	PhaseStorage       = "storage"
+	PhaseService       = "service"
+	PhaseTraffic       = "traffic"
+)
+
+// DefaultPhases is the phase order used when NewLifecycle is called without phases.
+var DefaultPhases = []string{PhaseObservability, PhaseStorage, PhaseService, PhaseTraffic}
+
+// HookFunc defines the function signature for lifecycle start and stop hooks.
+type HookFunc func(ctx context.Context) error
+
+// Hook describes a component registered in a Lifecycle.
+type Hook struct {
+	// Name identifies the hook in errors and logs.
+	Name string
+	// Phase is the lifecycle phase the hook belongs to. Defaults to PhaseService.
+	Phase string
+	// Priority orders hooks within a phase: lower values start first and stop last.
+	Priority int
+	// Timeout bounds each call to OnStart and OnStop. Zero means no timeout.
+	Timeout time.Duration
+	// OnStart is called when the lifecycle starts. Optional.
+	OnStart HookFunc
+	// OnStop is called when the lifecycle stops. Optional.
+	OnStop HookFunc
+}
+
+// Lifecycle starts registered hooks phase by phase and stops them in reverse order.
+type Lifecycle struct {
+	mu      sync.Mutex
+	phases  []string
+	hooks   []Hook
+	started map[int]bool
+	stopped bool
+}
+
+// NewLifecycle creates a Lifecycle with the given phases in start order.
+// If no phases are provided, DefaultPhases is used.
+func NewLifecycle(phases ...string) *Lifecycle {
+	if len(phases) == 0 {
+		phases = DefaultPhases
+	}
+	return &Lifecycle{
+		phases:  slices.Clone(phases),
+		started: make(map[int]bool),
+	}
+}
+
+// Append registers a hook. It returns an error if the hook has no name or its phase is unknown.
+func (l *Lifecycle) Append(hook Hook) error {
+	if hook.Name == "" {
+		return errors.New("lifecycle hook name is required")
+	}
+	if hook.Phase == "" {
+		hook.Phase = PhaseService
+	}
+	if !slices.Contains(l.phases, hook.Phase) {
+		return fmt.Errorf("unknown lifecycle phase %q for hook %q", hook.Phase, hook.Name)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, hook)
+	return nil
+}
+
+// Start runs the OnStart function of every hook in phase and priority order.
+// If a hook fails, the hooks ordered before it, including those without OnStart, are stopped in
+// reverse order and all errors are returned joined. Hooks ordered after it are not stopped.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	order := l.order()
+	for j, i := range order {
+		hook := l.hooks[i]
+		if hook.OnStart == nil || l.started[i] {
+			continue
+		}
+		if err := runHook(ctx, hook.Timeout, hook.OnStart); err != nil {
+			startErr := fmt.Errorf("start %s/%s: %w", hook.Phase, hook.Name, err)
+			return errors.Join(startErr, l.stop(ctx, order[:j]))
+		}
+		l.started[i] = true
+	}
+
+	return nil
+}
+
+// Stop runs the OnStop function of every started hook in reverse phase and priority order.
+// Hooks without OnStart are always considered started. All errors are returned joined.
+// Calling Stop more than once is a no-op.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stop(ctx, l.order())
+}
+
+// Run starts the lifecycle, waits for a shutdown signal or context cancellation, and stops it.
+// See the ShutdownOption functions for grace period, signal and logging settings.
+func (l *Lifecycle) Run(ctx context.Context, opts ...ShutdownOption) error {
+	return l.run(ctx, newShutdownConfig(opts))
+}
+
+func (l *Lifecycle) run(ctx context.Context, cfg *shutdownConfig) error {
+	if err := l.Start(ctx); err != nil {
+		return err
+	}
+
+	// Create a channel to listen for shutdown signals.
+	sigChan := make(chan os.Signal, 1)
+	cfg.source.Notify(sigChan, cfg.signals...)
+	defer cfg.source.Stop(sigChan)
+
+	reloadCtx, cancelReload := context.WithCancel(ctx)
+	var reloaders sync.WaitGroup
+	for _, r := range cfg.reloaders {
+		reloaders.Add(1)
+		go func() {
+			defer reloaders.Done()
+			r.Run(reloadCtx)
+		}()
+	}
+
+	select {
+	case <-ctx.Done():
+		cfg.infof("Context cancelled, shutting down...")
+	case sig := <-sigChan:
+		cfg.infof("Received signal: %s, shutting down...", sig)
+	}
+
+	cancelReload()
+
+	stopCtx := context.Background()
+	if cfg.gracePeriod > 0 {
+		var cancel context.CancelFunc
+		stopCtx, cancel = context.WithTimeout(stopCtx, cfg.gracePeriod)
+		defer cancel()
+	}
+
+	// Reload hooks ignoring the cancellation are awaited within the grace period, and a second signal
+	// still forces the exit while they run.
+	done := make(chan error, 1)
+	go func() {
+		reloaders.Wait()
+		done <- l.Stop(stopCtx)
+	}()
+
+	select {
+	case err := <-done:
+		for _, t := range cfg.leakTrackers {
+			t.Report()
+		}
+		return err
+	case <-stopCtx.Done():
+		cfg.errorf("Shutdown did not complete within %s", cfg.gracePeriod)
+		return ErrShutdownTimeout
+	case sig := <-sigChan:
+		cfg.errorf("Received signal: %s during shutdown, forcing exit", sig)
+		cfg.exit(cfg.forceExitCode)
+		return ErrForcedExit
+	}
+}
+
+// stop stops the hooks of order, given in start order, in reverse. It must be called with l.mu held.
+func (l *Lifecycle) stop(ctx context.Context, order []int) error {
+	if l.stopped {
+		return nil
+	}
+	l.stopped = true
+
+	var errs []error
+	for j := len(order) - 1; j >= 0; j-- {
+		i := order[j]
+		hook := l.hooks[i]
+		if hook.OnStop == nil || (hook.OnStart != nil && !l.started[i]) {
+			continue
+		}
+		if err := runHook(ctx, hook.Timeout, hook.OnStop); err != nil {
+			errs = append(errs, fmt.Errorf("stop %s/%s: %w", hook.Phase, hook.Name, err))
+		}
+		delete(l.started, i)
+	}
+
+	return errors.Join(errs...)
+}
+
+// order returns the hook indexes sorted by phase and priority, keeping registration order on ties.
+func (l *Lifecycle) order() []int {
+	idx := make([]int, len(l.hooks))
+	for i := range idx {
+		idx[i] = i
+	}
+	slices.SortStableFunc(idx, func(a, b int) int {
+		pa := slices.Index(l.phases, l.hooks[a].Phase)
+		pb := slices.Index(l.phases, l.hooks[b].Phase)
+		if pa != pb {
+			return cmp.Compare(pa, pb)
+		}
+		return cmp.Compare(l.hooks[a].Priority, l.hooks[b].Priority)
+	})
+	return idx
+}
+
+// runHook calls fn, returning early with the context error if the timeout elapses first.
+func runHook(ctx context.Context, timeout time.Duration, fn HookFunc) error {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,152 @@
+package ctxutils
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder collects the names of executed hooks in order.
+type recorder struct {
+	calls []string
+}
+
+func (r *recorder) hook(name string, err error) HookFunc {
+	return func(ctx context.Context) error {
+		r.calls = append(r.calls, name)
+		return err
+	}
+}
+
+func TestLifecycle_Append(t *testing.T) {
+	lc := NewLifecycle()
+
+	assert.Error(t, lc.Append(Hook{}), "hook without name should be rejected")
+	assert.Error(t, lc.Append(Hook{Name: "x", Phase: "unknown"}), "hook with unknown phase should be rejected")
+	assert.NoError(t, lc.Append(Hook{Name: "x"}), "hook without phase should default to service")
+}
+
+func TestLifecycle_StartStopOrder(t *testing.T) {
+	rec := &recorder{}
+	lc := NewLifecycle()
+
+	hooks := []Hook{
+		{Name: "http", Phase: PhaseTraffic, OnStart: rec.hook("start http", nil), OnStop: rec.hook("stop http", nil)},
+		{Name: "db", Phase: PhaseStorage, OnStart: rec.hook("start db", nil), OnStop: rec.hook("stop db", nil)},
+		{Name: "logger", Phase: PhaseObservability, OnStop: rec.hook("stop logger", nil)},
+		{Name: "worker-b", Phase: PhaseService, Priority: 2, OnStart: rec.hook("start worker-b", nil), OnStop: rec.hook("stop worker-b", nil)},
+		{Name: "worker-a", Phase: PhaseService, Priority: 1, OnStart: rec.hook("start worker-a", nil), OnStop: rec.hook("stop worker-a", nil)},
+	}
+	for _, h := range hooks {
+		require.NoError(t, lc.Append(h))
+	}
+
+	require.NoError(t, lc.Start(context.Background()))
+	require.NoError(t, lc.Stop(context.Background()))
+
+	expected := []string{
+		"start db", "start worker-a", "start worker-b", "start http",
+		"stop http", "stop worker-b", "stop worker-a", "stop db", "stop logger",
+	}
+	assert.Equal(t, expected, rec.calls)
+
+	// A second Stop must not run hooks again.
+	require.NoError(t, lc.Stop(context.Background()))
+	assert.Len(t, rec.calls, len(expected))
+}
+
+func TestLifecycle_ExtremePriorities(t *testing.T) {
+	rec := &recorder{}
+	lc := NewLifecycle()
+
+	hooks := []Hook{
+		{Name: "max", Phase: PhaseTraffic, Priority: math.MaxInt, OnStart: rec.hook("start max", nil), OnStop: rec.hook("stop max", nil)},
+		{Name: "negative", Phase: PhaseTraffic, Priority: -10, OnStart: rec.hook("start negative", nil), OnStop: rec.hook("stop negative", nil)},
+		{Name: "min", Phase: PhaseTraffic, Priority: math.MinInt, OnStart: rec.hook("start min", nil), OnStop: rec.hook("stop min", nil)},
+	}
+	for _, h := range hooks {
+		require.NoError(t, lc.Append(h))
+	}
+
+	require.NoError(t, lc.Start(context.Background()))
+	require.NoError(t, lc.Stop(context.Background()))
+
+	assert.Equal(t, []string{
+		"start min", "start negative", "start max",
+		"stop max", "stop negative", "stop min",
+	}, rec.calls)
+}
+
+func TestLifecycle_StartFailureRollsBack(t *testing.T) {
+	rec := &recorder{}
+	lc := NewLifecycle()
+	startErr := errors.New("boom")
+	stopErr := errors.New("db close failed")
+
+	require.NoError(t, lc.Append(Hook{Name: "db", Phase: PhaseStorage, OnStart: rec.hook("start db", nil), OnStop: rec.hook("stop db", stopErr)}))
+	require.NoError(t, lc.Append(Hook{Name: "cache", Phase: PhaseService, OnStart: rec.hook("start cache", startErr), OnStop: rec.hook("stop cache", nil)}))
+	require.NoError(t, lc.Append(Hook{Name: "http", Phase: PhaseTraffic, OnStart: rec.hook("start http", nil), OnStop: rec.hook("stop http", nil)}))
+	require.NoError(t, lc.Append(Hook{Name: "logger", Phase: PhaseObservability, OnStop: rec.hook("stop logger", nil)}))
+	require.NoError(t, lc.Append(Hook{Name: "drain", Phase: PhaseTraffic, OnStop: rec.hook("stop drain", nil)}))
+	require.NoError(t, lc.Append(Hook{Name: "queue", Phase: PhaseService, Priority: 1, OnStop: rec.hook("stop queue", nil)}))
+
+	err := lc.Start(context.Background())
+
+	assert.ErrorIs(t, err, startErr)
+	assert.ErrorIs(t, err, stopErr)
+	assert.Equal(t, []string{"start db", "start cache", "stop db", "stop logger"}, rec.calls,
+		"OnStop-only hooks ordered after the failed one are not stopped")
+}
+
+func TestLifecycle_StopAggregatesErrors(t *testing.T) {
+	lc := NewLifecycle()
+	errA := errors.New("a failed")
+	errB := errors.New("b failed")
+
+	require.NoError(t, lc.Append(Hook{Name: "a", OnStop: func(context.Context) error { return errA }}))
+	require.NoError(t, lc.Append(Hook{Name: "b", OnStop: func(context.Context) error { return errB }}))
+
+	err := lc.Stop(context.Background())
+
+	assert.ErrorIs(t, err, errA)
+	assert.ErrorIs(t, err, errB)
+	assert.Contains(t, err.Error(), "stop service/a")
+}
+
+func TestLifecycle_HookTimeout(t *testing.T) {
+	lc := NewLifecycle()
+	require.NoError(t, lc.Append(Hook{
+		Name:    "slow",
+		Timeout: 50 * time.Millisecond,
+		OnStop: func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		},
+	}))
+
+	start := time.Now()
+	err := lc.Stop(context.Background())
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestLifecycle_RunStopsOnContextCancel(t *testing.T) {
+	rec := &recorder{}
+	lc := NewLifecycle()
+	require.NoError(t, lc.Append(Hook{Name: "svc", OnStart: rec.hook("start", nil), OnStop: rec.hook("stop", nil)}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	require.NoError(t, lc.Run(ctx))
+	assert.Equal(t, []string{"start", "stop"}, rec.calls)
+}
@@ -0,0 +1,361 @@
+package ctxutils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Sectoid-Systems/sectoid-go-kit/misc"
+)
+
+// ErrPoolClosed is returned by Acquire once the pool has been closed.
+var ErrPoolClosed = errors.New("pool is closed")
+
+// PoolConfig configures a Pool.
+type PoolConfig[T Closeable] struct {
+	// Factory creates a new item. Required.
+	Factory func(ctx context.Context) (T, error)
+	// MaxSize is the maximum number of items, idle and in use. Required.
+	MaxSize int
+	// IdleTimeout closes items that stayed idle for longer. Zero disables it.
+	IdleTimeout time.Duration
+	// MaxLifetime closes items older than this, once they are idle. Zero disables it.
+	MaxLifetime time.Duration
+	// Validate is called on borrow; items failing validation are closed and replaced. Optional.
+	Validate func(T) error
+	// Logger reports errors closing items, unless they are returned by CloseContext. Optional.
+	Logger misc.LoggerFunc
+}
+
+// PoolStats is a snapshot of the pool usage.
+type PoolStats struct {
+	InUse    int
+	Idle     int
+	Waits    int64
+	WaitTime time.Duration
+}
+
+type pooledItem[T Closeable] struct {
+	value     T
+	createdAt time.Time
+	idleSince time.Time
+}
+
+// Pool is a bounded pool of Closeable items.
+type Pool[T Closeable] struct {
+	cfg     PoolConfig[T]
+	sem     chan struct{}
+	done    chan struct{}
+	drained chan struct{}
+
+	mu       sync.Mutex
+	idle     []*pooledItem[T]
+	inUse    int
+	waits    int64
+	waitTime time.Duration
+	closed   bool
+	// waiting is the number of CloseContext calls waiting for the items in use, whose closing errors
+	// are collected in releaseErrs.
+	waiting     int
+	releaseErrs []error
+}
+
+// Lease is an item borrowed from a Pool. It must be returned with Release or Discard.
+type Lease[T Closeable] struct {
+	pool *Pool[T]
+	item *pooledItem[T]
+	once sync.Once
+}
+
+// NewPool creates a Pool. Items are created lazily by Acquire.
+func NewPool[T Closeable](cfg PoolConfig[T]) (*Pool[T], error) {
+	if cfg.Factory == nil {
+		return nil, errors.New("pool factory is required")
+	}
+	if cfg.MaxSize <= 0 {
+		return nil, errors.New("pool max size must be positive")
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = func(string, ...any) {}
+	}
+
+	p := &Pool[T]{
+		cfg:     cfg,
+		sem:     make(chan struct{}, cfg.MaxSize),
+		done:    make(chan struct{}),
+		drained: make(chan struct{}),
+	}
+
+	if interval := reapInterval(cfg.IdleTimeout, cfg.MaxLifetime); interval > 0 {
+		go p.reap(interval)
+	}
+
+	return p, nil
+}
+
+// Acquire borrows an idle item or creates a new one, blocking while the pool is exhausted
+// until an item is released, the context is done or the pool is closed.
+func (p *Pool[T]) Acquire(ctx context.Context) (*Lease[T], error) {
+	if err := p.acquireSlot(ctx); err != nil {
+		return nil, err
+	}
+
+	for {
+		item, err := p.popIdle()
+		if err != nil {
+			<-p.sem
+			return nil, err
+		}
+		if item == nil {
+			break
+		}
+		if p.cfg.Validate != nil {
+			if err := p.cfg.Validate(item.value); err != nil {
+				p.closeItem(item)
+				continue
+			}
+		}
+		return p.lease(item)
+	}
+
+	v, err := p.cfg.Factory(ctx)
+	if err != nil {
+		<-p.sem
+		return nil, err
+	}
+
+	return p.lease(&pooledItem[T]{value: v, createdAt: time.Now()})
+}
+
+// Stats returns a snapshot of the pool usage.
+func (p *Pool[T]) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return PoolStats{
+		InUse:    p.inUse,
+		Idle:     len(p.idle),
+		Waits:    p.waits,
+		WaitTime: p.waitTime,
+	}
+}
+
+// Close closes every idle item and makes further Acquire calls fail with ErrPoolClosed. It does not wait for
+// the items still in use: they are closed when they are released, and their closing errors are reported to
+// the Logger. Use CloseContext to wait for them. Closing errors of idle items are returned joined.
+func (p *Pool[T]) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+	if p.inUse == 0 {
+		close(p.drained)
+	}
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	var errs []error
+	for _, item := range idle {
+		if err := item.value.Close(); err != nil {
+			errs = append(errs, &CloseError{Name: "pool item", Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CloseContext closes the pool like Close, then waits until every item in use is released and closed, or the
+// context is done. It returns the closing errors of the idle items and of the items released while waiting,
+// joined with the context error if the context is done first. Items released later are closed as with Close.
+func (p *Pool[T]) CloseContext(ctx context.Context) error {
+	p.mu.Lock()
+	p.waiting++
+	p.mu.Unlock()
+
+	errs := []error{p.Close()}
+	select {
+	case <-p.drained:
+	case <-ctx.Done():
+		errs = append(errs, ctx.Err())
+	}
+
+	p.mu.Lock()
+	p.waiting--
+	errs = append(errs, p.releaseErrs...)
+	p.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// Value returns the borrowed item.
+func (l *Lease[T]) Value() T {
+	return l.item.value
+}
+
+// Release returns the item to the pool. Calling it more than once has no effect.
+func (l *Lease[T]) Release() {
+	l.once.Do(func() {
+		l.pool.release(l.item, false)
+	})
+}
+
+// Discard closes the item instead of returning it to the pool, e.g. after a connection error.
+func (l *Lease[T]) Discard() {
+	l.once.Do(func() {
+		l.pool.release(l.item, true)
+	})
+}
+
+func (p *Pool[T]) acquireSlot(ctx context.Context) error {
+	select {
+	case <-p.done:
+		return ErrPoolClosed
+	case p.sem <- struct{}{}:
+		return nil
+	default:
+	}
+
+	start := time.Now()
+	defer func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.waits++
+		p.waitTime += time.Since(start)
+	}()
+
+	select {
+	case p.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.done:
+		return ErrPoolClosed
+	}
+}
+
+// popIdle removes the most recently used idle item that has not expired, closing expired ones.
+// It returns nil when no idle item is left.
+func (p *Pool[T]) popIdle() (*pooledItem[T], error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, ErrPoolClosed
+	}
+
+	now := time.Now()
+	var expired []*pooledItem[T]
+	var item *pooledItem[T]
+	for len(p.idle) > 0 {
+		last := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if p.expired(last, now) {
+			expired = append(expired, last)
+			continue
+		}
+		item = last
+		break
+	}
+	p.mu.Unlock()
+
+	for _, e := range expired {
+		p.closeItem(e)
+	}
+	return item, nil
+}
+
+// lease marks an item as in use, or closes it if the pool was closed while it was acquired.
+func (p *Pool[T]) lease(item *pooledItem[T]) (*Lease[T], error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		p.closeItem(item)
+		<-p.sem
+		return nil, ErrPoolClosed
+	}
+	p.inUse++
+	p.mu.Unlock()
+	return &Lease[T]{pool: p, item: item}, nil
+}
+
+func (p *Pool[T]) release(item *pooledItem[T], discard bool) {
+	p.mu.Lock()
+	keep := !discard && !p.closed && !p.expired(item, time.Now())
+	if keep {
+		p.inUse--
+		item.idleSince = time.Now()
+		p.idle = append(p.idle, item)
+	}
+	p.mu.Unlock()
+
+	if !keep {
+		err := item.value.Close()
+
+		p.mu.Lock()
+		p.inUse--
+		if err != nil && p.closed && p.waiting > 0 {
+			p.releaseErrs = append(p.releaseErrs, &CloseError{Name: "pool item", Err: err})
+			err = nil
+		}
+		if p.closed && p.inUse == 0 {
+			close(p.drained)
+		}
+		p.mu.Unlock()
+
+		if err != nil {
+			p.cfg.Logger("error closing resource: %v", err)
+		}
+	}
+	<-p.sem
+}
+
+func (p *Pool[T]) expired(item *pooledItem[T], now time.Time) bool {
+	if p.cfg.MaxLifetime > 0 && now.Sub(item.createdAt) >= p.cfg.MaxLifetime {
+		return true
+	}
+	return p.cfg.IdleTimeout > 0 && !item.idleSince.IsZero() && now.Sub(item.idleSince) >= p.cfg.IdleTimeout
+}
+
+func (p *Pool[T]) closeItem(item *pooledItem[T]) {
+	CloseResource(item.value, p.cfg.Logger)
+}
+
+// reap periodically closes idle items that expired, until the pool is closed.
+func (p *Pool[T]) reap(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case now := <-ticker.C:
+			p.mu.Lock()
+			var expired []*pooledItem[T]
+			kept := p.idle[:0]
+			for _, item := range p.idle {
+				if p.expired(item, now) {
+					expired = append(expired, item)
+				} else {
+					kept = append(kept, item)
+				}
+			}
+			p.idle = kept
+			p.mu.Unlock()
+
+			for _, item := range expired {
+				p.closeItem(item)
+			}
+		}
+	}
+}
+
+// reapInterval returns half of the shortest positive timeout, or zero when both are disabled.
+func reapInterval(idleTimeout, maxLifetime time.Duration) time.Duration {
+	interval := idleTimeout
+	if maxLifetime > 0 && (interval <= 0 || maxLifetime < interval) {
+		interval = maxLifetime
+	}
+	return interval / 2
+}
@@ -0,0 +1,230 @@
+package ctxutils
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// poolConn is a Closeable counting how many times it has been closed.
+type poolConn struct {
+	id       int64
+	closed   atomic.Int32
+	broken   bool
+	closeErr error
+}
+
+func (c *poolConn) Close() error {
+	c.closed.Add(1)
+	return c.closeErr
+}
+
+func newTestPool(t *testing.T, cfg PoolConfig[*poolConn]) (*Pool[*poolConn], *atomic.Int64) {
+	t.Helper()
+	var created atomic.Int64
+	cfg.Factory = func(ctx context.Context) (*poolConn, error) {
+		return &poolConn{id: created.Add(1)}, nil
+	}
+	p, err := NewPool(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = p.Close() })
+	return p, &created
+}
+
+func TestNewPool_InvalidConfig(t *testing.T) {
+	_, err := NewPool(PoolConfig[*poolConn]{MaxSize: 1})
+	assert.Error(t, err)
+
+	_, err = NewPool(PoolConfig[*poolConn]{Factory: func(context.Context) (*poolConn, error) { return nil, nil }})
+	assert.Error(t, err)
+}
+
+func TestPool_ReusesItems(t *testing.T) {
+	p, created := newTestPool(t, PoolConfig[*poolConn]{MaxSize: 2})
+
+	lease, err := p.Acquire(context.Background())
+	require.NoError(t, err)
+	first := lease.Value()
+	assert.Equal(t, PoolStats{InUse: 1}, p.Stats())
+	lease.Release()
+	lease.Release()
+
+	lease, err = p.Acquire(context.Background())
+	require.NoError(t, err)
+	assert.Same(t, first, lease.Value())
+	assert.Equal(t, int64(1), created.Load())
+	lease.Release()
+
+	assert.Equal(t, PoolStats{Idle: 1}, p.Stats())
+}
+
+func TestPool_BlocksWhenExhausted(t *testing.T) {
+	p, _ := newTestPool(t, PoolConfig[*poolConn]{MaxSize: 1})
+
+	lease, err := p.Acquire(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = p.Acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		lease.Release()
+	}()
+	second, err := p.Acquire(context.Background())
+	require.NoError(t, err)
+	second.Release()
+
+	stats := p.Stats()
+	assert.Equal(t, int64(2), stats.Waits)
+	assert.GreaterOrEqual(t, stats.WaitTime, 40*time.Millisecond)
+}
+
+func TestPool_ValidateOnBorrow(t *testing.T) {
+	p, created := newTestPool(t, PoolConfig[*poolConn]{
+		MaxSize: 1,
+		Validate: func(c *poolConn) error {
+			if c.broken {
+				return errors.New("broken")
+			}
+			return nil
+		},
+	})
+
+	lease, err := p.Acquire(context.Background())
+	require.NoError(t, err)
+	broken := lease.Value()
+	broken.broken = true
+	lease.Release()
+
+	lease, err = p.Acquire(context.Background())
+	require.NoError(t, err)
+	defer lease.Release()
+
+	assert.NotSame(t, broken, lease.Value())
+	assert.Equal(t, int32(1), broken.closed.Load())
+	assert.Equal(t, int64(2), created.Load())
+}
+
+func TestPool_IdleTimeoutAndMaxLifetime(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  PoolConfig[*poolConn]
+	}{
+		{"Idle timeout", PoolConfig[*poolConn]{MaxSize: 1, IdleTimeout: 20 * time.Millisecond}},
+		{"Max lifetime", PoolConfig[*poolConn]{MaxSize: 1, MaxLifetime: 20 * time.Millisecond}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestPool(t, tt.cfg)
+
+			lease, err := p.Acquire(context.Background())
+			require.NoError(t, err)
+			item := lease.Value()
+			lease.Release()
+
+			assert.Eventually(t, func() bool {
+				return item.closed.Load() == 1 && p.Stats().Idle == 0
+			}, time.Second, 5*time.Millisecond, "expired idle items should be reaped")
+		})
+	}
+}
+
+func TestPool_Discard(t *testing.T) {
+	p, _ := newTestPool(t, PoolConfig[*poolConn]{MaxSize: 1})
+
+	lease, err := p.Acquire(context.Background())
+	require.NoError(t, err)
+	item := lease.Value()
+	lease.Discard()
+
+	assert.Equal(t, int32(1), item.closed.Load())
+	assert.Equal(t, PoolStats{}, p.Stats())
+}
+
+func TestPool_Close(t *testing.T) {
+	p, _ := newTestPool(t, PoolConfig[*poolConn]{MaxSize: 2})
+
+	idleLease, err := p.Acquire(context.Background())
+	require.NoError(t, err)
+	busyLease, err := p.Acquire(context.Background())
+	require.NoError(t, err)
+	idle, busy := idleLease.Value(), busyLease.Value()
+	idleLease.Release()
+
+	require.NoError(t, p.Close())
+
+	assert.Equal(t, int32(1), idle.closed.Load())
+	assert.Equal(t, int32(0), busy.closed.Load(), "items in use are closed on release")
+	busyLease.Release()
+	assert.Equal(t, int32(1), busy.closed.Load())
+
+	_, err = p.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrPoolClosed)
+}
+
+func TestPool_CloseContext(t *testing.T) {
+	errClose := errors.New("close failed")
+	p, _ := newTestPool(t, PoolConfig[*poolConn]{MaxSize: 2})
+
+	lease, err := p.Acquire(context.Background())
+	require.NoError(t, err)
+	lease.Value().closeErr = errClose
+
+	closed := make(chan error, 1)
+	go func() { closed <- p.CloseContext(context.Background()) }()
+
+	select {
+	case err := <-closed:
+		t.Fatalf("CloseContext returned before the lease was released: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	_, err = p.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrPoolClosed)
+
+	lease.Release()
+	err = <-closed
+	assert.ErrorIs(t, err, errClose)
+	var ce *CloseError
+	require.ErrorAs(t, err, &ce)
+	assert.Equal(t, "pool item", ce.Name)
+}
+
+func TestPool_CloseContextTimeout(t *testing.T) {
+	p, _ := newTestPool(t, PoolConfig[*poolConn]{MaxSize: 1})
+	lease, err := p.Acquire(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = p.CloseContext(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	item := lease.Value()
+	lease.Release()
+	assert.Equal(t, int32(1), item.closed.Load(), "items released after the timeout are still closed")
+}
+
+func TestPool_CloseUnblocksWaiters(t *testing.T) {
+	p, _ := newTestPool(t, PoolConfig[*poolConn]{MaxSize: 1})
+	lease, err := p.Acquire(context.Background())
+	require.NoError(t, err)
+	defer lease.Release()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = p.Close()
+	}()
+
+	_, err = p.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrPoolClosed)
+}
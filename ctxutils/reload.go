@@ -0,0 +1,130 @@
+package ctxutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Sectoid-Systems/sectoid-go-kit/misc"
+)
+
+// ReloadFunc defines the function signature for reload hooks.
+type ReloadFunc func(ctx context.Context) error
+
+type reloadHook struct {
+	name    string
+	timeout time.Duration
+	fn      ReloadFunc
+}
+
+// ReloadOption configures a Reloader.
+type ReloadOption func(*Reloader)
+
+// WithReloadSignals replaces the default SIGHUP signal that triggers a reload.
+func WithReloadSignals(sigs ...os.Signal) ReloadOption {
+	return func(r *Reloader) {
+		r.signals = sigs
+	}
+}
+
+// WithReloadSignalSource replaces the os/signal based source, typically to inject signals in tests.
+func WithReloadSignalSource(src SignalSource) ReloadOption {
+	return func(r *Reloader) {
+		r.source = src
+	}
+}
+
+// Reloader is a registry of hooks run when the process is asked to reload,
+// either by a signal (SIGHUP by default) or programmatically via Trigger.
+// Hooks run serially; failures are reported but never stop the process.
+type Reloader struct {
+	mu      sync.Mutex
+	running sync.Mutex
+	hooks   []reloadHook
+	trigger chan struct{}
+	signals []os.Signal
+	source  SignalSource
+	lf      misc.LoggerFunc
+}
+
+// NewReloader creates a Reloader reporting hook failures through lf.
+func NewReloader(lf misc.LoggerFunc, opts ...ReloadOption) *Reloader {
+	r := &Reloader{
+		trigger: make(chan struct{}, 1),
+		signals: []os.Signal{syscall.SIGHUP},
+		source:  osSignals{},
+		lf:      lf,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Register adds a reload hook. A zero timeout means the hook is only bounded by the reload context.
+func (r *Reloader) Register(name string, timeout time.Duration, fn ReloadFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, reloadHook{name: name, timeout: timeout, fn: fn})
+}
+
+// Trigger requests a reload from code. Requests made while a reload is pending are coalesced.
+// It has no effect unless Run is active.
+func (r *Reloader) Trigger() {
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Reload runs every registered hook serially in registration order and returns their errors joined.
+// Each failure is also reported through the logger function. Concurrent calls are serialized.
+func (r *Reloader) Reload(ctx context.Context) error {
+	r.running.Lock()
+	defer r.running.Unlock()
+
+	r.mu.Lock()
+	hooks := make([]reloadHook, len(r.hooks))
+	copy(hooks, r.hooks)
+	r.mu.Unlock()
+
+	var errs []error
+	for _, hook := range hooks {
+		if ctx.Err() != nil {
+			errs = append(errs, fmt.Errorf("reload %s: %w", hook.name, ctx.Err()))
+			break
+		}
+		if err := runHook(ctx, hook.timeout, HookFunc(hook.fn)); err != nil {
+			err = fmt.Errorf("reload %s: %w", hook.name, err)
+			r.lf("reload hook failed: %v", err)
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Run listens for reload signals and triggers until the context is cancelled.
+// A reload in progress receives the cancellation and Run returns once it has finished.
+func (r *Reloader) Run(ctx context.Context) {
+	sigChan := make(chan os.Signal, 1)
+	r.source.Notify(sigChan, r.signals...)
+	defer r.source.Stop(sigChan)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-sigChan:
+			r.lf("Received signal: %s, reloading...", sig)
+		case <-r.trigger:
+			r.lf("Reload triggered, reloading...")
+		}
+
+		_ = r.Reload(ctx)
+	}
+}
@@ -0,0 +1,190 @@
+package ctxutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloader_ReloadRunsHooksSerially(t *testing.T) {
+	lm := &logMock{}
+	r := NewReloader(lm.Logf)
+	failure := errors.New("bad certificate")
+
+	var calls []string
+	r.Register("config", 0, func(ctx context.Context) error {
+		calls = append(calls, "config")
+		return nil
+	})
+	r.Register("certs", 0, func(ctx context.Context) error {
+		calls = append(calls, "certs")
+		return failure
+	})
+	r.Register("log-level", 0, func(ctx context.Context) error {
+		calls = append(calls, "log-level")
+		return nil
+	})
+
+	err := r.Reload(context.Background())
+
+	assert.ErrorIs(t, err, failure)
+	assert.Contains(t, err.Error(), "reload certs")
+	assert.Equal(t, []string{"config", "certs", "log-level"}, calls, "a failing hook must not prevent the others from running")
+	assert.True(t, lm.Called, "failures should be reported")
+}
+
+func TestReloader_HookTimeout(t *testing.T) {
+	r := NewReloader(func(string, ...any) {})
+	r.Register("slow", 20*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	err := r.Reload(context.Background())
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestReloader_RunOnSignalAndTrigger(t *testing.T) {
+	src := &fakeSignals{}
+	r := NewReloader(func(string, ...any) {}, WithReloadSignalSource(src))
+
+	reloads := make(chan struct{}, 2)
+	r.Register("counter", 0, func(ctx context.Context) error {
+		reloads <- struct{}{}
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+
+	src.Send(syscall.SIGHUP)
+	waitFor(t, reloads)
+
+	r.Trigger()
+	waitFor(t, reloads)
+
+	cancel()
+	waitFor(t, done)
+	assert.Equal(t, []os.Signal{syscall.SIGHUP}, src.sigs)
+}
+
+func TestWaitForShutdown_CancelsReloadInProgress(t *testing.T) {
+	reloadSignals := &fakeSignals{}
+	r := NewReloader(func(string, ...any) {}, WithReloadSignalSource(reloadSignals))
+
+	var mu sync.Mutex
+	var events []string
+	record := func(e string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	}
+
+	reloading := make(chan struct{})
+	r.Register("slow", 0, func(ctx context.Context) error {
+		close(reloading)
+		<-ctx.Done()
+		record("reload cancelled")
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		reloadSignals.Send(syscall.SIGHUP)
+		<-reloading
+		cancel()
+	}()
+
+	WaitForShutdown(ctx, func() error {
+		record("shutdown")
+		return nil
+	}, WithReloader(r), WithSignalSource(&fakeSignals{}), WithLoggerFunc(func(string, ...any) {}))
+
+	require.Len(t, events, 2)
+	assert.Equal(t, []string{"reload cancelled", "shutdown"}, events)
+}
+
+func TestWaitForShutdown_StuckReloadBoundedByGracePeriod(t *testing.T) {
+	tests := []struct {
+		name     string
+		signals  []os.Signal
+		expected string
+	}{
+		{"Grace period", []os.Signal{syscall.SIGTERM}, ErrShutdownTimeout.Error()},
+		{"Second signal", []os.Signal{syscall.SIGTERM, syscall.SIGINT}, "forcing exit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reloadSignals := &fakeSignals{}
+			r := NewReloader(func(string, ...any) {}, WithReloadSignalSource(reloadSignals))
+
+			release := make(chan struct{})
+			defer close(release)
+			reloading := make(chan struct{})
+			r.Register("stuck", 0, func(context.Context) error {
+				close(reloading)
+				<-release
+				return nil
+			})
+
+			src := &fakeSignals{}
+			go func() {
+				reloadSignals.Send(syscall.SIGHUP)
+				<-reloading
+				for _, sig := range tt.signals {
+					src.Send(sig)
+				}
+			}()
+
+			var mu sync.Mutex
+			var logs []string
+			lf := func(format string, v ...any) {
+				mu.Lock()
+				defer mu.Unlock()
+				logs = append(logs, fmt.Sprintf(format, v...))
+			}
+
+			gracePeriod := 100 * time.Millisecond
+			if len(tt.signals) > 1 {
+				gracePeriod = time.Minute
+			}
+			start := time.Now()
+			WaitForShutdown(context.Background(), func() error { return nil },
+				WithReloader(r),
+				WithGracePeriod(gracePeriod),
+				WithSignalSource(src),
+				WithExitFunc(func(int) {}),
+				WithLoggerFunc(lf))
+
+			assert.Less(t, time.Since(start), time.Second, "the stuck reload hook does not delay shutdown")
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Contains(t, strings.Join(logs, "\n"), tt.expected)
+		})
+	}
+}
+
+// waitFor fails the test if nothing is received from ch within a second.
+func waitFor[T any](t *testing.T, ch <-chan T) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+}
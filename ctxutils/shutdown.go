@@ -1,35 +1,28 @@
 package ctxutils
 
-import (
-	"context"
-	"log"
-	"os"
-	"os/signal"
-	"syscall"
-)
+import "context"
 
 // ShutdownFunc defines the function signature for shutdown procedures.
 type ShutdownFunc func() error
 
 // WaitForShutdown waits for an interrupt or context cancellation signal to perform a shutdown.
-func WaitForShutdown(ctx context.Context, shutdown ShutdownFunc) {
-	// Create a channel to listen for interrupt signals.
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	defer signal.Stop(sigChan)
-	defer close(sigChan)
+// It is a thin wrapper over a Lifecycle holding a single stop hook.
+// Options allow setting a grace period, the signal set and the logger; a second
+// signal received while shutting down forces the process to exit.
+func WaitForShutdown(ctx context.Context, shutdown ShutdownFunc, opts ...ShutdownOption) {
+	cfg := newShutdownConfig(opts)
 
-	select {
-	case <-ctx.Done():
-		log.Println("Context cancelled, shutting down...")
-	case sig := <-sigChan:
-		log.Printf("Received signal: %s, shutting down...", sig)
-	}
+	lc := NewLifecycle()
+	_ = lc.Append(Hook{
+		Name: "shutdown",
+		OnStop: func(context.Context) error {
+			return shutdown()
+		},
+	})
 
-	// Execute the shutdown function.
-	if err := shutdown(); err != nil {
-		log.Printf("Shutdown error: %s", err)
+	if err := lc.run(ctx, cfg); err != nil {
+		cfg.errorf("Shutdown error: %s", err)
 	}
 
-	log.Println("Bye!")
+	cfg.infof("Bye!")
 }
@@ -3,12 +3,17 @@ package ctxutils
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"os"
 	"strings"
+	"sync"
 	"syscall"
 	"testing"
 	"time"
+
+	"github.com/Sectoid-Systems/sectoid-go-kit/logmesh"
+	"github.com/stretchr/testify/assert"
 )
 
 // sendSignal is a helper function to send a signal after a delay.
@@ -97,3 +102,142 @@ func TestWaitForShutdown_ShutdownError(t *testing.T) {
 		t.Error("expected shutdown to be called on signal received")
 	}
 }
+
+// fakeSignals is a SignalSource that delivers signals sent through its Send method.
+type fakeSignals struct {
+	mu   sync.Mutex
+	subs []chan<- os.Signal
+	sigs []os.Signal
+}
+
+func (f *fakeSignals) Notify(c chan<- os.Signal, sig ...os.Signal) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subs = append(f.subs, c)
+	f.sigs = sig
+}
+
+func (f *fakeSignals) Stop(c chan<- os.Signal) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subs = nil
+}
+
+// Send delivers sig to every subscriber, waiting until at least one is registered.
+func (f *fakeSignals) Send(sig os.Signal) {
+	for {
+		f.mu.Lock()
+		if len(f.subs) > 0 {
+			for _, c := range f.subs {
+				c <- sig
+			}
+			f.mu.Unlock()
+			return
+		}
+		f.mu.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWaitForShutdown_CustomSignals(t *testing.T) {
+	src := &fakeSignals{}
+	shutdownCalled := make(chan bool, 1)
+
+	go src.Send(syscall.SIGUSR1)
+
+	WaitForShutdown(context.Background(), func() error {
+		shutdownCalled <- true
+		return nil
+	}, WithSignalSource(src), WithSignals(syscall.SIGUSR1), WithLoggerFunc(func(string, ...any) {}))
+
+	assert.True(t, <-shutdownCalled)
+	assert.Equal(t, []os.Signal{syscall.SIGUSR1}, src.sigs)
+}
+
+func TestWaitForShutdown_GracePeriod(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var logs []string
+	lf := func(format string, v ...any) {
+		logs = append(logs, fmt.Sprintf(format, v...))
+	}
+
+	start := time.Now()
+	WaitForShutdown(ctx, func() error {
+		time.Sleep(time.Second)
+		return nil
+	}, WithGracePeriod(50*time.Millisecond), WithSignalSource(&fakeSignals{}), WithLoggerFunc(lf))
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Contains(t, strings.Join(logs, "\n"), ErrShutdownTimeout.Error())
+}
+
+func TestWaitForShutdown_ForceExitOnSecondSignal(t *testing.T) {
+	src := &fakeSignals{}
+	release := make(chan struct{})
+	defer close(release)
+
+	exitCode := make(chan int, 1)
+	go func() {
+		src.Send(syscall.SIGTERM)
+		src.Send(syscall.SIGINT)
+	}()
+
+	WaitForShutdown(context.Background(), func() error {
+		<-release
+		return nil
+	},
+		WithSignalSource(src),
+		WithForceExitCode(42),
+		WithExitFunc(func(code int) { exitCode <- code }),
+		WithLoggerFunc(func(string, ...any) {}),
+	)
+
+	assert.Equal(t, 42, <-exitCode)
+}
+
+func TestLifecycle_RunWithLogger(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	logger := &loggerMock{}
+	lc := NewLifecycle()
+
+	err := lc.Run(ctx, WithLogger(logger), WithSignalSource(&fakeSignals{}))
+
+	assert.NoError(t, err)
+	assert.Contains(t, logger.infos, "Context cancelled, shutting down...")
+}
+
+// loggerMock is a logmesh.Logger recording formatted Info and Error messages.
+type loggerMock struct {
+	mu     sync.Mutex
+	infos  []string
+	errors []string
+}
+
+func (l *loggerMock) Infof(format string, v ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.infos = append(l.infos, fmt.Sprintf(format, v...))
+}
+
+func (l *loggerMock) Errorf(format string, v ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errors = append(l.errors, fmt.Sprintf(format, v...))
+}
+
+func (l *loggerMock) Info(...any)                        {}
+func (l *loggerMock) Debug(...any)                       {}
+func (l *loggerMock) Debugf(string, ...any)              {}
+func (l *loggerMock) Warn(...any)                        {}
+func (l *loggerMock) Warnf(string, ...any)               {}
+func (l *loggerMock) Error(...any)                       {}
+func (l *loggerMock) Panicf(string, ...any)              {}
+func (l *loggerMock) DPanicf(string, ...any)             {}
+func (l *loggerMock) With(string, string) logmesh.Logger { return l }
+func (l *loggerMock) Child(string) logmesh.Logger        { return l }
+func (l *loggerMock) Flush()                             {}
+func (l *loggerMock) Close() error                       { return nil }
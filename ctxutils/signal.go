@@ -0,0 +1,133 @@
+package ctxutils
+
+import (
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Sectoid-Systems/sectoid-go-kit/logmesh"
+	"github.com/Sectoid-Systems/sectoid-go-kit/misc"
+)
+
+// DefaultForceExitCode is the exit code used when a second signal forces the process to exit.
+const DefaultForceExitCode = 130
+
+var (
+	// ErrShutdownTimeout is returned when the shutdown procedures exceed the grace period.
+	ErrShutdownTimeout = errors.New("shutdown grace period exceeded")
+	// ErrForcedExit is returned when a second signal forced the exit while shutting down.
+	ErrForcedExit = errors.New("shutdown forced by second signal")
+)
+
+// SignalSource abstracts the delivery of OS signals, allowing tests to inject signals.
+// Its method set matches the os/signal package.
+type SignalSource interface {
+	Notify(c chan<- os.Signal, sig ...os.Signal)
+	Stop(c chan<- os.Signal)
+}
+
+// osSignals is the SignalSource backed by the os/signal package.
+type osSignals struct{}
+
+func (osSignals) Notify(c chan<- os.Signal, sig ...os.Signal) { signal.Notify(c, sig...) }
+func (osSignals) Stop(c chan<- os.Signal)                     { signal.Stop(c) }
+
+// ShutdownOption configures WaitForShutdown and Lifecycle.Run.
+type ShutdownOption func(*shutdownConfig)
+
+type shutdownConfig struct {
+	gracePeriod   time.Duration
+	signals       []os.Signal
+	source        SignalSource
+	infof         misc.LoggerFunc
+	errorf        misc.LoggerFunc
+	forceExitCode int
+	exit          func(code int)
+	reloaders     []*Reloader
+	leakTrackers  []*LeakTracker
+}
+
+func newShutdownConfig(opts []ShutdownOption) *shutdownConfig {
+	cfg := &shutdownConfig{
+		signals:       []os.Signal{os.Interrupt, syscall.SIGTERM},
+		source:        osSignals{},
+		infof:         log.Printf,
+		errorf:        log.Printf,
+		forceExitCode: DefaultForceExitCode,
+		exit:          os.Exit,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithGracePeriod bounds the total time spent running shutdown procedures.
+// When the period elapses, the shutdown is abandoned and ErrShutdownTimeout is reported.
+func WithGracePeriod(d time.Duration) ShutdownOption {
+	return func(c *shutdownConfig) {
+		c.gracePeriod = d
+	}
+}
+
+// WithSignals replaces the default set of signals (SIGINT and SIGTERM) that trigger a shutdown.
+func WithSignals(sigs ...os.Signal) ShutdownOption {
+	return func(c *shutdownConfig) {
+		c.signals = sigs
+	}
+}
+
+// WithSignalSource replaces the os/signal based source, typically to inject signals in tests.
+func WithSignalSource(src SignalSource) ShutdownOption {
+	return func(c *shutdownConfig) {
+		c.source = src
+	}
+}
+
+// WithForceExitCode sets the exit code used when a second signal forces the exit.
+func WithForceExitCode(code int) ShutdownOption {
+	return func(c *shutdownConfig) {
+		c.forceExitCode = code
+	}
+}
+
+// WithExitFunc replaces os.Exit as the function called to force the exit.
+func WithExitFunc(exit func(code int)) ShutdownOption {
+	return func(c *shutdownConfig) {
+		c.exit = exit
+	}
+}
+
+// WithLoggerFunc sets the function used to report shutdown progress and errors.
+func WithLoggerFunc(lf misc.LoggerFunc) ShutdownOption {
+	return func(c *shutdownConfig) {
+		c.infof = lf
+		c.errorf = lf
+	}
+}
+
+// WithLogger reports shutdown progress at Info level and errors at Error level on the given logger.
+func WithLogger(logger logmesh.Logger) ShutdownOption {
+	return func(c *shutdownConfig) {
+		c.infof = logger.Infof
+		c.errorf = logger.Errorf
+	}
+}
+
+// WithReloader runs the Reloader while waiting for shutdown. When shutdown begins,
+// any reload in progress is cancelled and awaited before the stop hooks run, within the grace period.
+func WithReloader(r *Reloader) ShutdownOption {
+	return func(c *shutdownConfig) {
+		c.reloaders = append(c.reloaders, r)
+	}
+}
+
+// WithLeakTracker reports the resources of the tracker still open once the shutdown procedures completed.
+func WithLeakTracker(t *LeakTracker) ShutdownOption {
+	return func(c *shutdownConfig) {
+		c.leakTrackers = append(c.leakTrackers, t)
+	}
+}
@@ -0,0 +1,290 @@
+package ctxutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Sectoid-Systems/sectoid-go-kit/misc"
+)
+
+// ErrRestartIntensity is returned by a Supervisor when a child fails more often than allowed.
+var ErrRestartIntensity = errors.New("supervisor restart intensity exceeded")
+
+// Runnable is a long-lived service run by a Supervisor. Run must return when the context is cancelled.
+type Runnable interface {
+	Run(ctx context.Context) error
+}
+
+// RunnableFunc adapts a plain function to the Runnable interface.
+type RunnableFunc func(ctx context.Context) error
+
+// Run calls f.
+func (f RunnableFunc) Run(ctx context.Context) error {
+	return f(ctx)
+}
+
+// RestartPolicy defines when a Supervisor restarts a child that returned.
+type RestartPolicy int
+
+const (
+	// Permanent children are always restarted, even when they return nil.
+	Permanent RestartPolicy = iota
+	// Transient children are restarted only when they return an error or panic.
+	Transient
+	// Temporary children are never restarted.
+	Temporary
+)
+
+type child struct {
+	name   string
+	r      Runnable
+	policy RestartPolicy
+}
+
+// SupervisorOption configures a Supervisor.
+type SupervisorOption func(*Supervisor)
+
+// WithBackoff sets the delay before the first restart and the maximum delay.
+// The delay doubles with every restart within the intensity window.
+func WithBackoff(initial, max time.Duration) SupervisorOption {
+	return func(s *Supervisor) {
+		s.initialBackoff = initial
+		s.maxBackoff = max
+	}
+}
+
+// WithRestartIntensity sets how many restarts of a single child are tolerated within the window
+// before the supervisor escalates by stopping every child and returning ErrRestartIntensity.
+func WithRestartIntensity(maxRestarts int, window time.Duration) SupervisorOption {
+	return func(s *Supervisor) {
+		s.maxRestarts = maxRestarts
+		s.window = window
+	}
+}
+
+// WithSupervisorLogger sets the function used to report child failures and restarts.
+func WithSupervisorLogger(lf misc.LoggerFunc) SupervisorOption {
+	return func(s *Supervisor) {
+		s.lf = lf
+	}
+}
+
+// Supervisor runs Runnable children and restarts them according to their RestartPolicy.
+type Supervisor struct {
+	mu             sync.Mutex
+	children       []child
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	maxRestarts    int
+	window         time.Duration
+	lf             misc.LoggerFunc
+
+	// Set while running.
+	ctx      context.Context
+	wg       sync.WaitGroup
+	escalate chan error
+
+	// Set by Start.
+	started *startedRun
+}
+
+// startedRun is a Run started in the background by Start. err is set before done is closed.
+type startedRun struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+// NewSupervisor creates a Supervisor. By default restarts back off from 100ms up to 30s,
+// and a child may restart 5 times per minute before the supervisor escalates.
+func NewSupervisor(opts ...SupervisorOption) *Supervisor {
+	s := &Supervisor{
+		initialBackoff: 100 * time.Millisecond,
+		maxBackoff:     30 * time.Second,
+		maxRestarts:    5,
+		window:         time.Minute,
+		lf:             func(string, ...any) {},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Add registers a child. If the supervisor is already running, the child is started immediately.
+func (s *Supervisor) Add(name string, r Runnable, policy RestartPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := child{name: name, r: r, policy: policy}
+	s.children = append(s.children, c)
+	if s.ctx != nil {
+		s.spawn(c)
+	}
+}
+
+// Run starts every child and blocks until the context is cancelled or a child exceeds the restart intensity.
+// All children are stopped before Run returns. It returns nil on cancellation and an error wrapping
+// ErrRestartIntensity on escalation.
+func (s *Supervisor) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s.mu.Lock()
+	if s.ctx != nil {
+		s.mu.Unlock()
+		return errors.New("supervisor is already running")
+	}
+	s.ctx = ctx
+	s.escalate = make(chan error, 1)
+	for _, c := range s.children {
+		s.spawn(c)
+	}
+	s.mu.Unlock()
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-s.escalate:
+		s.lf("supervisor escalating: %v", err)
+	}
+
+	cancel()
+	s.mu.Lock()
+	s.ctx = nil
+	s.mu.Unlock()
+	s.wg.Wait()
+
+	return err
+}
+
+// Start runs the supervisor in the background. Together with Stop it can be used as Lifecycle hooks.
+// It fails if the supervisor is already running; it can be started again once it has stopped.
+func (s *Supervisor) Start(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx != nil || (s.started != nil && !isDone(s.started.done)) {
+		return errors.New("supervisor is already running")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &startedRun{cancel: cancel, done: make(chan struct{})}
+	s.started = r
+	go func() {
+		r.err = s.Run(ctx)
+		close(r.done)
+	}()
+	return nil
+}
+
+// Stop cancels a supervisor started with Start and waits for its children to return,
+// up to the context deadline. It returns the escalation error, if any. Once the supervisor
+// has stopped, further calls return the same result.
+func (s *Supervisor) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	r := s.started
+	s.mu.Unlock()
+	if r == nil {
+		return nil
+	}
+	r.cancel()
+
+	select {
+	case <-r.done:
+		return r.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isDone reports whether a channel is closed.
+func isDone(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+// spawn must be called with s.mu held.
+func (s *Supervisor) spawn(c child) {
+	ctx, escalate := s.ctx, s.escalate
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.supervise(ctx, c, escalate)
+	}()
+}
+
+// supervise runs a child until the context is cancelled, its policy says it should not restart,
+// or it exceeds the restart intensity.
+func (s *Supervisor) supervise(ctx context.Context, c child, escalate chan<- error) {
+	var restarts []time.Time
+
+	for {
+		err := callSafely(func() error {
+			return c.r.Run(ctx)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		var pe *PanicError
+		switch {
+		case errors.As(err, &pe):
+			s.lf("supervisor: child %s panicked: %v\n%s", c.name, pe.Value, pe.Stack)
+		case err != nil:
+			s.lf("supervisor: child %s failed: %v", c.name, err)
+		}
+
+		if c.policy == Temporary || (c.policy == Transient && err == nil) {
+			return
+		}
+
+		now := time.Now()
+		restarts = append(pruneBefore(restarts, now.Add(-s.window)), now)
+		if len(restarts) > s.maxRestarts {
+			if err == nil {
+				err = errors.New("child returned")
+			}
+			select {
+			case escalate <- fmt.Errorf("%w: child %s restarted %d times within %s: %w", ErrRestartIntensity, c.name, s.maxRestarts, s.window, err):
+			default:
+			}
+			return
+		}
+
+		delay := s.backoff(len(restarts))
+		s.lf("supervisor: restarting child %s in %s", c.name, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the delay before the given restart attempt.
+func (s *Supervisor) backoff(attempt int) time.Duration {
+	delay := s.initialBackoff
+	for i := 1; i < attempt && delay < s.maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, s.maxBackoff)
+}
+
+// pruneBefore drops the times before the cutoff from a chronologically ordered slice.
+func pruneBefore(times []time.Time, cutoff time.Time) []time.Time {
+	for len(times) > 0 && times[0].Before(cutoff) {
+		times = times[1:]
+	}
+	return times
+}
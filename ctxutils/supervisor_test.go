@@ -0,0 +1,167 @@
+package ctxutils
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSupervisor(opts ...SupervisorOption) *Supervisor {
+	return NewSupervisor(append([]SupervisorOption{WithBackoff(time.Millisecond, 5*time.Millisecond)}, opts...)...)
+}
+
+func TestSupervisor_RestartPolicies(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   RestartPolicy
+		err      error
+		expected int32
+	}{
+		{"Permanent restarts after success", Permanent, nil, 3},
+		{"Transient restarts after failure", Transient, errors.New("failure"), 3},
+		{"Transient does not restart after success", Transient, nil, 1},
+		{"Temporary never restarts", Temporary, errors.New("failure"), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSupervisor(WithRestartIntensity(100, time.Minute))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var runs atomic.Int32
+			s.Add("child", RunnableFunc(func(ctx context.Context) error {
+				if runs.Add(1) >= tt.expected && tt.expected > 1 {
+					<-ctx.Done()
+					return nil
+				}
+				return tt.err
+			}), tt.policy)
+
+			done := make(chan error, 1)
+			go func() { done <- s.Run(ctx) }()
+
+			time.Sleep(100 * time.Millisecond)
+			cancel()
+
+			assert.NoError(t, <-done)
+			assert.Equal(t, tt.expected, runs.Load())
+		})
+	}
+}
+
+func TestSupervisor_RecoversPanics(t *testing.T) {
+	lm := &logMock{}
+	s := newTestSupervisor(WithSupervisorLogger(lm.Logf))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var runs atomic.Int32
+	s.Add("panicky", RunnableFunc(func(ctx context.Context) error {
+		if runs.Add(1) == 1 {
+			panic("boom")
+		}
+		<-ctx.Done()
+		return nil
+	}), Transient)
+
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+
+	assert.Eventually(t, func() bool { return runs.Load() == 2 }, time.Second, time.Millisecond)
+	cancel()
+
+	assert.NoError(t, <-done)
+	assert.True(t, lm.Called, "the panic should be reported")
+}
+
+func TestSupervisor_Escalates(t *testing.T) {
+	s := newTestSupervisor(WithRestartIntensity(2, time.Minute))
+	failure := errors.New("cannot connect")
+
+	stopped := make(chan struct{})
+	s.Add("healthy", RunnableFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		close(stopped)
+		return nil
+	}), Permanent)
+	s.Add("broken", RunnableFunc(func(ctx context.Context) error {
+		return failure
+	}), Permanent)
+
+	err := s.Run(context.Background())
+
+	assert.ErrorIs(t, err, ErrRestartIntensity)
+	assert.ErrorIs(t, err, failure)
+	assert.Contains(t, err.Error(), "broken")
+	waitFor(t, stopped)
+}
+
+func TestSupervisor_AddWhileRunning(t *testing.T) {
+	s := newTestSupervisor()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+
+	started := make(chan struct{})
+	assert.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.ctx != nil
+	}, time.Second, time.Millisecond)
+	s.Add("late", RunnableFunc(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return nil
+	}), Permanent)
+
+	waitFor(t, started)
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestSupervisor_LifecycleHooks(t *testing.T) {
+	s := newTestSupervisor()
+	stopped := make(chan struct{})
+	s.Add("worker", RunnableFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		close(stopped)
+		return nil
+	}), Permanent)
+
+	lc := NewLifecycle()
+	require.NoError(t, lc.Append(Hook{Name: "workers", OnStart: s.Start, OnStop: s.Stop}))
+
+	require.NoError(t, lc.Start(context.Background()))
+	require.NoError(t, lc.Stop(context.Background()))
+	waitFor(t, stopped)
+}
+
+func TestSupervisor_StartStopTwice(t *testing.T) {
+	s := newTestSupervisor()
+	var runs atomic.Int32
+	s.Add("worker", RunnableFunc(func(ctx context.Context) error {
+		runs.Add(1)
+		<-ctx.Done()
+		return nil
+	}), Permanent)
+
+	require.NoError(t, s.Start(context.Background()))
+	assert.Error(t, s.Start(context.Background()), "already running")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, s.Stop(ctx))
+	require.NoError(t, s.Stop(ctx), "a second Stop returns the same result without blocking")
+
+	require.NoError(t, s.Start(context.Background()), "a stopped supervisor can be started again")
+	require.NoError(t, s.Stop(ctx))
+	assert.Equal(t, int32(2), runs.Load())
+}
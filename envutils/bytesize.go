@@ -0,0 +1,106 @@
+package envutils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a size in bytes, parsed from human-readable values such as "512MiB" or "1.5GB".
+// It can be used as a field type with Load.
+type ByteSize uint64
+
+// Decimal and binary byte size units.
+const (
+	Byte ByteSize = 1
+
+	KB ByteSize = 1000 * Byte
+	MB ByteSize = 1000 * KB
+	GB ByteSize = 1000 * MB
+	TB ByteSize = 1000 * GB
+	PB ByteSize = 1000 * TB
+
+	KiB ByteSize = 1024 * Byte
+	MiB ByteSize = 1024 * KiB
+	GiB ByteSize = 1024 * MiB
+	TiB ByteSize = 1024 * GiB
+	PiB ByteSize = 1024 * TiB
+)
+
+var byteSizeUnits = map[string]ByteSize{
+	"": Byte, "b": Byte,
+	"k": KB, "kb": KB, "m": MB, "mb": MB, "g": GB, "gb": GB, "t": TB, "tb": TB, "p": PB, "pb": PB,
+	"ki": KiB, "kib": KiB, "mi": MiB, "mib": MiB, "gi": GiB, "gib": GiB, "ti": TiB, "tib": TiB, "pi": PiB, "pib": PiB,
+}
+
+// byteSizeNames lists the units used by String, largest first.
+var byteSizeNames = []struct {
+	size ByteSize
+	name string
+}{
+	{PiB, "PiB"}, {PB, "PB"}, {TiB, "TiB"}, {TB, "TB"}, {GiB, "GiB"}, {GB, "GB"},
+	{MiB, "MiB"}, {MB, "MB"}, {KiB, "KiB"}, {KB, "KB"},
+}
+
+// ParseByteSize parses a size made of a number, possibly fractional, and an optional unit:
+// B, KB, MB, GB, TB and PB are powers of 1000, KiB, MiB, GiB, TiB and PiB powers of 1024.
+// Units are case-insensitive, and the trailing B may be omitted: "10k", "512Mi" and "1.5 GB" are valid.
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	number, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+
+	mult, ok := byteSizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown byte size unit %q", s[i:])
+	}
+	if number == "" {
+		return 0, strconv.ErrSyntax
+	}
+
+	if u, err := strconv.ParseUint(number, 10, 64); err == nil {
+		if u > math.MaxUint64/uint64(mult) {
+			return 0, strconv.ErrRange
+		}
+		return ByteSize(u) * mult, nil
+	}
+
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, strconv.ErrSyntax
+	}
+	size := f * float64(mult)
+	if size >= math.MaxUint64 {
+		return 0, strconv.ErrRange
+	}
+	return ByteSize(size), nil
+}
+
+// String formats the size with the largest unit dividing it exactly, e.g. "512MiB", or in bytes.
+func (b ByteSize) String() string {
+	for _, u := range byteSizeNames {
+		if b >= u.size && b%u.size == 0 {
+			return strconv.FormatUint(uint64(b/u.size), 10) + u.name
+		}
+	}
+	return strconv.FormatUint(uint64(b), 10) + "B"
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, with ParseByteSize.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
@@ -0,0 +1,102 @@
+package envutils
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected ByteSize
+	}{
+		{"0", 0},
+		{"1024", 1024},
+		{"10B", 10},
+		{"10k", 10 * KB},
+		{"10KB", 10 * KB},
+		{"512MiB", 512 * MiB},
+		{"512Mi", 512 * MiB},
+		{"512 mib", 512 * MiB},
+		{"1.5GB", 1500 * MB},
+		{"1.5GiB", 1536 * MiB},
+		{"2TiB", 2 * TiB},
+		{"1PB", PB},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			b, err := ParseByteSize(tt.input)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, b)
+		})
+	}
+}
+
+func TestParseByteSize_Errors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   error
+	}{
+		{"", strconv.ErrSyntax},
+		{"MiB", strconv.ErrSyntax},
+		{"1.2.3MB", strconv.ErrSyntax},
+		{"-1MB", nil},
+		{"10XB", nil},
+		{"20000000PiB", strconv.ErrRange},
+		{"20000000.5PiB", strconv.ErrRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := ParseByteSize(tt.input)
+
+			require.Error(t, err)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestByteSize_String(t *testing.T) {
+	tests := []struct {
+		size     ByteSize
+		expected string
+	}{
+		{0, "0B"},
+		{1000, "1KB"},
+		{1023, "1023B"},
+		{512 * MiB, "512MiB"},
+		{1500 * MB, "1500MB"},
+		{3 * GiB, "3GiB"},
+		{1536 * MiB, "1536MiB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.size.String())
+
+			parsed, err := ParseByteSize(tt.size.String())
+			require.NoError(t, err)
+			assert.Equal(t, tt.size, parsed)
+		})
+	}
+}
+
+func TestLoad_ByteSize(t *testing.T) {
+	t.Setenv("MAX_BODY", "4MiB")
+
+	var cfg struct {
+		MaxBody  ByteSize `env:"MAX_BODY"`
+		MaxCache ByteSize `env:"MAX_CACHE" default:"1GB"`
+	}
+	require.NoError(t, Load(&cfg))
+
+	assert.Equal(t, 4*MiB, cfg.MaxBody)
+	assert.Equal(t, GB, cfg.MaxCache)
+}
@@ -0,0 +1,167 @@
+package envutils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EncryptedPrefix marks encrypted values: enc:v1:<base64 of the nonce followed by the AES-GCM ciphertext>.
+const EncryptedPrefix = "enc:v1:"
+
+// DefaultKeyVar is the variable holding the base64 encoded decryption key, or, with the _FILE suffix,
+// the path of a file holding it.
+const DefaultKeyVar = "ENVUTILS_KEY"
+
+var (
+	// ErrDecrypt is wrapped by errors of values that cannot be decrypted with any of the keys.
+	ErrDecrypt = errors.New("cannot decrypt value")
+	// ErrNoKey is wrapped by the VarError of an encrypted value loaded without decryption key.
+	ErrNoKey = errors.New("value is encrypted but no decryption key is configured")
+)
+
+// IsEncrypted reports whether the value has the EncryptedPrefix.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, EncryptedPrefix)
+}
+
+// GenerateKey returns a new random 256-bit key.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// EncodeKey encodes a key in base64, as read by ParseKey.
+func EncodeKey(key []byte) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// ParseKey decodes a base64 encoded AES key of 16, 24 or 32 bytes.
+func ParseKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("envutils: invalid key encoding: %w", err)
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("envutils: invalid key size %d, expected 16, 24 or 32 bytes", len(key))
+	}
+}
+
+// LoadKey reads a base64 encoded key from the variable named name, or from the file named by name with the _FILE suffix.
+func LoadKey(name string) ([]byte, error) {
+	value, ok, _, err := lookupWithFile(os.LookupEnv, name)
+	if err != nil {
+		return nil, fmt.Errorf("envutils: %s: %w", name, err)
+	}
+	if !ok || value == "" {
+		return nil, fmt.Errorf("envutils: neither %s nor %s is set", name, name+FileSuffix)
+	}
+	return ParseKey(value)
+}
+
+// Cipher encrypts and decrypts configuration values with AES-GCM.
+type Cipher struct {
+	aead     cipher.AEAD
+	previous []cipher.AEAD
+}
+
+// NewCipher creates a Cipher encrypting with key. Values are decrypted with key, then with the previous keys,
+// so that values encrypted with a former key can still be read while they are being rotated.
+func NewCipher(key []byte, previous ...[]byte) (*Cipher, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cipher{aead: aead}
+	for _, k := range previous {
+		p, err := newAEAD(k)
+		if err != nil {
+			return nil, err
+		}
+		c.previous = append(c.previous, p)
+	}
+	return c, nil
+}
+
+// NewCipherFromEnv creates a Cipher with the key read by LoadKey from DefaultKeyVar.
+func NewCipherFromEnv() (*Cipher, error) {
+	key, err := LoadKey(DefaultKeyVar)
+	if err != nil {
+		return nil, err
+	}
+	return NewCipher(key)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("envutils: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt encrypts a value with a random nonce and returns it with the EncryptedPrefix.
+func (c *Cipher) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return EncryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value produced by Encrypt. Values without the EncryptedPrefix are returned unchanged.
+func (c *Cipher) Decrypt(value string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, EncryptedPrefix)
+	if !ok {
+		return value, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid encoding", ErrDecrypt)
+	}
+
+	for _, aead := range append([]cipher.AEAD{c.aead}, c.previous...) {
+		if len(sealed) < aead.NonceSize() {
+			continue
+		}
+		nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+		if plaintext, err := aead.Open(nil, nonce, ciphertext, nil); err == nil {
+			return string(plaintext), nil
+		}
+	}
+	return "", ErrDecrypt
+}
+
+// Reencrypt decrypts an encrypted value, with any of the keys, and encrypts it again with the current key.
+// Values without the EncryptedPrefix are returned unchanged.
+func (c *Cipher) Reencrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	plaintext, err := c.Decrypt(value)
+	if err != nil {
+		return "", err
+	}
+	return c.Encrypt(plaintext)
+}
+
+// WithDecryption decrypts encrypted values with c. Without it, Load reports encrypted values as errors
+// wrapping ErrNoKey. Decrypted values are redacted from errors, like secrets.
+func WithDecryption(c *Cipher) LoadOption {
+	return func(l *loader) {
+		l.cipher = c
+	}
+}
@@ -0,0 +1,203 @@
+package envutils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCipher(t *testing.T, previous ...[]byte) (*Cipher, []byte) {
+	t.Helper()
+	key, err := GenerateKey()
+	require.NoError(t, err)
+	c, err := NewCipher(key, previous...)
+	require.NoError(t, err)
+	return c, key
+}
+
+func TestCipher_RoundTrip(t *testing.T) {
+	c, _ := newTestCipher(t)
+
+	enc, err := c.Encrypt("s3cr3t")
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(enc))
+	assert.NotContains(t, enc, "s3cr3t")
+
+	again, err := c.Encrypt("s3cr3t")
+	require.NoError(t, err)
+	assert.NotEqual(t, enc, again, "nonces are random")
+
+	dec, err := c.Decrypt(enc)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", dec)
+
+	plain, err := c.Decrypt("not encrypted")
+	require.NoError(t, err)
+	assert.Equal(t, "not encrypted", plain)
+}
+
+func TestCipher_DecryptErrors(t *testing.T) {
+	c, _ := newTestCipher(t)
+	other, _ := newTestCipher(t)
+	enc, err := other.Encrypt("s3cr3t")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"Wrong key", enc},
+		{"Invalid base64", EncryptedPrefix + "!!!"},
+		{"Too short", EncryptedPrefix + "AAAA"},
+		{"Tampered", enc[:len(enc)-4] + "AAA="},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.Decrypt(tt.value)
+			assert.ErrorIs(t, err, ErrDecrypt)
+		})
+	}
+}
+
+func TestCipher_Rotation(t *testing.T) {
+	old, oldKey := newTestCipher(t)
+	enc, err := old.Encrypt("s3cr3t")
+	require.NoError(t, err)
+
+	c, _ := newTestCipher(t, oldKey)
+
+	dec, err := c.Decrypt(enc)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", dec)
+
+	rotated, err := c.Reencrypt(enc)
+	require.NoError(t, err)
+	_, err = old.Decrypt(rotated)
+	assert.ErrorIs(t, err, ErrDecrypt, "rotated values use the new key")
+
+	plain, err := c.Reencrypt("plain")
+	require.NoError(t, err)
+	assert.Equal(t, "plain", plain)
+}
+
+func TestParseKey(t *testing.T) {
+	key, err := GenerateKey()
+	require.NoError(t, err)
+
+	parsed, err := ParseKey(" " + EncodeKey(key) + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, key, parsed)
+
+	_, err = ParseKey("not base64!")
+	assert.ErrorContains(t, err, "invalid key encoding")
+
+	_, err = ParseKey(EncodeKey([]byte("short")))
+	assert.ErrorContains(t, err, "invalid key size 5")
+
+	_, err = NewCipher([]byte("short"))
+	assert.Error(t, err)
+}
+
+func TestLoadKey(t *testing.T) {
+	key, err := GenerateKey()
+	require.NoError(t, err)
+
+	t.Run("Variable", func(t *testing.T) {
+		t.Setenv(DefaultKeyVar, EncodeKey(key))
+		c, err := NewCipherFromEnv()
+		require.NoError(t, err)
+		assert.NotNil(t, c)
+	})
+
+	t.Run("File", func(t *testing.T) {
+		t.Setenv(DefaultKeyVar+FileSuffix, writeSecret(t, EncodeKey(key)+"\n"))
+		loaded, err := LoadKey(DefaultKeyVar)
+		require.NoError(t, err)
+		assert.Equal(t, key, loaded)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		_, err := LoadKey("MISSING_KEY_VAR")
+		assert.EqualError(t, err, "envutils: neither MISSING_KEY_VAR nor MISSING_KEY_VAR_FILE is set")
+	})
+}
+
+func TestLoad_WithDecryption(t *testing.T) {
+	c, _ := newTestCipher(t)
+	password, err := c.Encrypt("  s3cr3t  ")
+	require.NoError(t, err)
+	port, err := c.Encrypt("not-a-port")
+	require.NoError(t, err)
+
+	var cfg struct {
+		Password string `env:"PASSWORD"`
+		Port     int    `env:"PORT"`
+		Plain    string `env:"PLAIN"`
+	}
+	src := MapSource("test", map[string]string{"PASSWORD": password, "PORT": port, "PLAIN": "plain"})
+	err = Load(&cfg, WithLookuper(src), WithDecryption(c))
+
+	var errs Errors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "s3cr3t", cfg.Password)
+	assert.Equal(t, "plain", cfg.Plain)
+	assert.Equal(t, "PORT", errs[0].Key)
+	assert.True(t, errs[0].Secret)
+	assert.NotContains(t, err.Error(), "not-a-port")
+
+	desc, err := Describe(&cfg, WithLookuper(src), WithDecryption(c))
+	require.NoError(t, err)
+	assert.Equal(t, "[REDACTED]", desc[0].Value)
+	assert.True(t, desc[0].Secret)
+	assert.Equal(t, "plain", desc[2].Value)
+}
+
+func TestLoad_EncryptedErrors(t *testing.T) {
+	c, _ := newTestCipher(t)
+	other, _ := newTestCipher(t)
+	enc, err := other.Encrypt("s3cr3t")
+	require.NoError(t, err)
+	src := MapSource("test", map[string]string{"PASSWORD": enc})
+
+	var cfg struct {
+		Password string `env:"PASSWORD"`
+	}
+
+	err = Load(&cfg, WithLookuper(src))
+	assert.ErrorIs(t, err, ErrNoKey)
+
+	err = Load(&cfg, WithLookuper(src), WithDecryption(c))
+	assert.ErrorIs(t, err, ErrDecrypt)
+	assert.Contains(t, err.Error(), "PASSWORD: cannot decrypt value (expected string)")
+	assert.False(t, strings.Contains(err.Error(), enc))
+}
+
+func TestGetEnv_EncryptedValue(t *testing.T) {
+	c, _ := newTestCipher(t)
+	enc, err := c.Encrypt("8080")
+	require.NoError(t, err)
+	t.Setenv("ENC_PORT", enc)
+
+	assert.Equal(t, "default", GetEnvWithDefault("ENC_PORT", "default"), "the ciphertext is never returned")
+	assert.Equal(t, 1, GetEnvAsInt("ENC_PORT", 1))
+
+	_, err = GetEnvStrict("ENC_PORT", "default")
+	assert.ErrorIs(t, err, ErrNoKey)
+	var ve *VarError
+	require.ErrorAs(t, err, &ve)
+	assert.Equal(t, "ENC_PORT", ve.Key)
+	assert.NotContains(t, err.Error(), enc)
+
+	_, err = GetEnvAsIntStrict("ENC_PORT", 1)
+	assert.ErrorIs(t, err, ErrNoKey)
+	_, err = GetEnvAsDuration("ENC_PORT", "1s")
+	assert.ErrorIs(t, err, ErrNoKey)
+
+	col := NewCollector()
+	col.RequiredString("ENC_PORT")
+	assert.ErrorIs(t, col.Err(), ErrNoKey)
+}
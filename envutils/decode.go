@@ -0,0 +1,167 @@
+package envutils
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+	urlType             = reflect.TypeOf(url.URL{})
+	locationPtrType     = reflect.TypeOf((*time.Location)(nil))
+)
+
+// decode parses raw into v according to its type.
+func decode(v reflect.Value, raw, sep, kvSep string) error {
+	// Locations are shared, and must not be copied.
+	if v.Type() == locationPtrType {
+		loc, err := time.LoadLocation(raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(loc))
+		return nil
+	}
+
+	if v.Kind() == reflect.Pointer {
+		elem := reflect.New(v.Type().Elem())
+		if err := decode(elem.Elem(), raw, sep, kvSep); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+
+	if v.Type() == urlType {
+		u, err := parseURL(raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(*u))
+		return nil
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		return decodeSlice(v, raw, sep, kvSep)
+	case reflect.Map:
+		return decodeMap(v, raw, sep, kvSep)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+func decodeSlice(v reflect.Value, raw, sep, kvSep string) error {
+	if v.Type().Elem().Kind() == reflect.Uint8 {
+		v.SetBytes([]byte(raw))
+		return nil
+	}
+
+	parts := splitTrimmed(raw, sep)
+	s := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+	for i, part := range parts {
+		if err := decode(s.Index(i), part, sep, kvSep); err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+	v.Set(s)
+	return nil
+}
+
+func decodeMap(v reflect.Value, raw, sep, kvSep string) error {
+	m := reflect.MakeMap(v.Type())
+	for _, pair := range splitTrimmed(raw, sep) {
+		k, val, ok := strings.Cut(pair, kvSep)
+		if !ok {
+			return fmt.Errorf("entry %q is missing the %q separator", pair, kvSep)
+		}
+
+		key := reflect.New(v.Type().Key()).Elem()
+		if err := decode(key, strings.TrimSpace(k), sep, kvSep); err != nil {
+			return fmt.Errorf("key %q: %w", k, err)
+		}
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if err := decode(elem, strings.TrimSpace(val), sep, kvSep); err != nil {
+			return fmt.Errorf("value of %q: %w", k, err)
+		}
+		m.SetMapIndex(key, elem)
+	}
+	v.Set(m)
+	return nil
+}
+
+// parseURL parses an absolute URL.
+func parseURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		// url.Error repeats the input; keep only the reason.
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			err = ue.Err
+		}
+		return nil, err
+	}
+	if !u.IsAbs() {
+		return nil, errors.New("URL is missing a scheme")
+	}
+	return u, nil
+}
+
+// splitTrimmed splits s by sep, trimming every part and dropping empty ones.
+func splitTrimmed(s, sep string) []string {
+	var parts []string
+	for _, p := range strings.Split(s, sep) {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
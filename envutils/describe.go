@@ -0,0 +1,181 @@
+package envutils
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Sectoid-Systems/sectoid-go-kit/logmesh"
+)
+
+// Sources reported by Describe besides the names of the sources read.
+const (
+	// SourceDefault is reported for fields set from their default tag.
+	SourceDefault = "default"
+	// SourceUnset is reported for fields whose variable is unset and have no default.
+	SourceUnset = "unset"
+)
+
+// FieldInfo describes the effective value of a configuration field.
+type FieldInfo struct {
+	// Field is the path of the field in the configuration struct, e.g. DB.Host.
+	Field string `json:"field"`
+	// Key is the name of the variable the field is read from.
+	Key string `json:"key"`
+	// Type is the type of the field.
+	Type string `json:"type"`
+	// Value is the value of the field, or [REDACTED] for secrets.
+	Value string `json:"value"`
+	// Source is the name of the source providing the value, suffixed with the _FILE variable for values
+	// read from files, e.g. env:DB_PASSWORD_FILE, or SourceDefault or SourceUnset.
+	Source string `json:"source"`
+	// Default reports whether the default value is used.
+	Default bool `json:"default"`
+	// Secret reports whether the value is masked.
+	Secret bool `json:"secret"`
+}
+
+// Description is the effective configuration, field by field, safe to print: secrets are masked.
+type Description []FieldInfo
+
+// Describe reports the value of every field of the loaded configuration cfg along with its source, masking
+// fields tagged secret:"true", values read from files and encrypted values. It must be given the options cfg was loaded with,
+// to read the same sources.
+//
+//	desc, err := envutils.Describe(&cfg, envutils.WithLookuper(merged))
+//	if err == nil {
+//		desc.Log(logger)
+//	}
+func Describe(cfg any, opts ...LoadOption) (Description, error) {
+	l, fields, err := prepare("Describe", cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	l.markOptionals(fields)
+
+	desc := make(Description, 0, len(fields))
+	for _, f := range fields {
+		info := FieldInfo{
+			Field:  f.name,
+			Key:    f.key,
+			Type:   typeName(f.value.Type()),
+			Secret: f.secret,
+		}
+
+		raw, ok, fromFile, err := lookupWithFile(l.lookup, f.key)
+		switch {
+		case f.skipped():
+			info.Source = SourceUnset
+		case err == nil && fromFile:
+			info.Source = l.origin(f.key+FileSuffix) + ":" + f.key + FileSuffix
+			info.Secret = true
+		case err == nil && ok && raw != "":
+			info.Source = l.origin(f.key)
+			info.Secret = info.Secret || IsEncrypted(raw)
+		case f.hasDefault:
+			info.Source = SourceDefault
+			info.Default = true
+		default:
+			info.Source = SourceUnset
+		}
+
+		info.Value = formatValue(f.value, f.sep, f.kvSep)
+		if info.Secret && info.Value != "" {
+			info.Value = redacted
+		}
+		desc = append(desc, info)
+	}
+	return desc, nil
+}
+
+// Table renders the description as an aligned text table.
+func (d Description) Table() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tFIELD\tTYPE\tVALUE\tSOURCE\tDEFAULT")
+	for _, f := range d {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%t\n", f.Key, f.Field, f.Type, f.Value, f.Source, f.Default)
+	}
+	_ = w.Flush()
+	return b.String()
+}
+
+// JSON renders the description as a JSON array.
+func (d Description) JSON() ([]byte, error) {
+	return json.Marshal(d)
+}
+
+// Log logs every field at info level, with its source and whether the default is used as fields.
+func (d Description) Log(logger logmesh.Logger) {
+	for _, f := range d {
+		logger.With("source", f.Source).With("default", strconv.FormatBool(f.Default)).
+			Infof("config %s=%s", f.Key, f.Value)
+	}
+}
+
+// formatValue formats a field the way Load parses it, so that it can be fed back.
+func formatValue(v reflect.Value, sep, kvSep string) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		if v.Type() == locationPtrType || v.Type().Elem() == urlType {
+			return v.Interface().(fmt.Stringer).String()
+		}
+		v = v.Elem()
+	}
+	if v.Type() == urlType {
+		u := v.Interface().(url.URL)
+		return u.String()
+	}
+
+	if m, ok := textMarshaler(v); ok {
+		if text, err := m.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	if v.Type() == durationType {
+		return v.Interface().(fmt.Stringer).String()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes())
+		}
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = formatValue(v.Index(i), sep, kvSep)
+		}
+		return strings.Join(parts, sep)
+	case reflect.Map:
+		parts := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			parts = append(parts, formatValue(iter.Key(), sep, kvSep)+kvSep+formatValue(iter.Value(), sep, kvSep))
+		}
+		sort.Strings(parts)
+		return strings.Join(parts, sep)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// textMarshaler returns v, or a pointer to it, as an encoding.TextMarshaler.
+func textMarshaler(v reflect.Value) (encoding.TextMarshaler, bool) {
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		return m, true
+	}
+	if v.CanAddr() {
+		m, ok := v.Addr().Interface().(encoding.TextMarshaler)
+		return m, ok
+	}
+	return nil, false
+}
@@ -0,0 +1,151 @@
+package envutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sectoid-Systems/sectoid-go-kit/logmesh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type describedConfig struct {
+	Name     string            `env:"NAME" required:"true"`
+	Port     int               `env:"PORT" default:"8080"`
+	Password string            `env:"PASSWORD" secret:"true"`
+	APIKey   string            `env:"API_KEY"`
+	Timeout  time.Duration     `env:"TIMEOUT" default:"30s"`
+	Hosts    []string          `env:"HOSTS" envSeparator:";"`
+	Labels   map[string]string `env:"LABELS"`
+	Level    level             `env:"LEVEL"`
+	Optional *int              `env:"OPTIONAL"`
+	DB       dbConfig          `envPrefix:"DB_"`
+}
+
+func TestDescribe(t *testing.T) {
+	m := Merge(
+		MapSource("defaults", map[string]string{"DB_HOST": "db.internal"}),
+		MapSource("env", map[string]string{
+			"NAME":         "orders",
+			"PASSWORD":     "s3cr3t",
+			"API_KEY_FILE": writeSecret(t, "k3y"),
+			"HOSTS":        "a;b",
+			"LABELS":       "tier=1,team=core",
+			"LEVEL":        "debug",
+		}),
+	)
+
+	var cfg describedConfig
+	require.NoError(t, Load(&cfg, WithLookuper(m)))
+
+	desc, err := Describe(&cfg, WithLookuper(m))
+	require.NoError(t, err)
+
+	assert.Equal(t, Description{
+		{Field: "Name", Key: "NAME", Type: "string", Value: "orders", Source: "env"},
+		{Field: "Port", Key: "PORT", Type: "int", Value: "8080", Source: SourceDefault, Default: true},
+		{Field: "Password", Key: "PASSWORD", Type: "string", Value: "[REDACTED]", Source: "env", Secret: true},
+		{Field: "APIKey", Key: "API_KEY", Type: "string", Value: "[REDACTED]", Source: "env:API_KEY_FILE", Secret: true},
+		{Field: "Timeout", Key: "TIMEOUT", Type: "time.Duration", Value: "30s", Source: SourceDefault, Default: true},
+		{Field: "Hosts", Key: "HOSTS", Type: "[]string", Value: "a;b", Source: "env"},
+		{Field: "Labels", Key: "LABELS", Type: "map[string]string", Value: "team=core,tier=1", Source: "env"},
+		{Field: "Level", Key: "LEVEL", Type: "envutils.level", Value: "DEBUG", Source: "env"},
+		{Field: "Optional", Key: "OPTIONAL", Type: "int", Value: "", Source: SourceUnset},
+		{Field: "DB.Host", Key: "DB_HOST", Type: "string", Value: "db.internal", Source: "defaults"},
+		{Field: "DB.Port", Key: "DB_PORT", Type: "int", Value: "5432", Source: SourceDefault, Default: true},
+	}, desc)
+}
+
+func TestDescribe_Env(t *testing.T) {
+	t.Setenv("HOST", "localhost")
+
+	cfg := dbConfig{Host: "localhost", Port: 5432}
+	desc, err := Describe(&cfg)
+
+	require.NoError(t, err)
+	assert.Equal(t, "env", desc[0].Source)
+	assert.Equal(t, SourceDefault, desc[1].Source)
+
+	_, err = Describe(cfg)
+	assert.Error(t, err)
+}
+
+func TestDescription_Table(t *testing.T) {
+	desc := Description{
+		{Field: "Port", Key: "PORT", Type: "int", Value: "8080", Source: SourceDefault, Default: true},
+		{Field: "Password", Key: "PASSWORD", Type: "string", Value: "[REDACTED]", Source: "env", Secret: true},
+	}
+
+	expected := strings.Join([]string{
+		"KEY       FIELD     TYPE    VALUE       SOURCE   DEFAULT",
+		"PORT      Port      int     8080        default  true",
+		"PASSWORD  Password  string  [REDACTED]  env      false",
+		"",
+	}, "\n")
+	assert.Equal(t, expected, desc.Table())
+}
+
+func TestDescription_JSON(t *testing.T) {
+	desc := Description{{Field: "Port", Key: "PORT", Type: "int", Value: "8080", Source: SourceDefault, Default: true}}
+
+	data, err := desc.JSON()
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"field":"Port","key":"PORT","type":"int","value":"8080","source":"default","default":true,"secret":false}]`, string(data))
+
+	var decoded Description
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, desc, decoded)
+}
+
+func TestDescription_Log(t *testing.T) {
+	logger := &loggerMock{}
+	desc := Description{
+		{Key: "PORT", Value: "8080", Source: SourceDefault, Default: true},
+		{Key: "PASSWORD", Value: "[REDACTED]", Source: "env", Secret: true},
+	}
+
+	desc.Log(logger)
+
+	assert.Equal(t, []string{
+		"config PORT=8080 source=default default=true",
+		"config PASSWORD=[REDACTED] source=env default=false",
+	}, logger.infos)
+}
+
+// loggerMock is a logmesh.Logger recording formatted Info messages, followed by their fields.
+type loggerMock struct {
+	fields []string
+	infos  []string
+	parent *loggerMock
+}
+
+func (l *loggerMock) Infof(format string, v ...any) {
+	msg := strings.Join(append([]string{fmt.Sprintf(format, v...)}, l.fields...), " ")
+	root := l
+	for root.parent != nil {
+		root = root.parent
+	}
+	root.infos = append(root.infos, msg)
+}
+
+func (l *loggerMock) With(key, value string) logmesh.Logger {
+	fields := append(append([]string(nil), l.fields...), key+"="+value)
+	return &loggerMock{fields: fields, parent: l}
+}
+
+func (l *loggerMock) Info(...any)                 {}
+func (l *loggerMock) Debug(...any)                {}
+func (l *loggerMock) Debugf(string, ...any)       {}
+func (l *loggerMock) Warn(...any)                 {}
+func (l *loggerMock) Warnf(string, ...any)        {}
+func (l *loggerMock) Error(...any)                {}
+func (l *loggerMock) Errorf(string, ...any)       {}
+func (l *loggerMock) Panicf(string, ...any)       {}
+func (l *loggerMock) DPanicf(string, ...any)      {}
+func (l *loggerMock) Child(string) logmesh.Logger { return l }
+func (l *loggerMock) Flush()                      {}
+func (l *loggerMock) Close() error                { return nil }
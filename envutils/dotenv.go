@@ -0,0 +1,288 @@
+package envutils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// DotEnvError reports a syntax error in a .env file.
+type DotEnvError struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *DotEnvError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+// LoadDotEnv reads the .env files in order and sets their variables in the process environment.
+// Variables already set in the process environment are not overridden; among the files, later ones
+// take precedence over earlier ones.
+func LoadDotEnv(files ...string) error {
+	return loadDotEnv(files, false)
+}
+
+// OverloadDotEnv reads the .env files in order and sets their variables in the process environment,
+// overriding variables already set.
+func OverloadDotEnv(files ...string) error {
+	return loadDotEnv(files, true)
+}
+
+// ReadDotEnv reads the .env files in order and returns their variables without modifying the process environment.
+// Interpolated variables not defined in the files are resolved from the process environment.
+func ReadDotEnv(files ...string) (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, file := range files {
+		if err := readDotEnvFile(file, vars, true); err != nil {
+			return nil, err
+		}
+	}
+	return vars, nil
+}
+
+// ParseDotEnv parses .env content. The name is only used in error messages.
+//
+// The format supports comments starting with #, an optional "export" prefix, unquoted values
+// (trimmed, with inline comments after " #"), single-quoted literal values, double-quoted values
+// with \n, \t, \r, \", \\ and \$ escapes, quoted values spanning multiple lines, and
+// ${VAR}, ${VAR:-default} and $VAR interpolation in unquoted and double-quoted values.
+func ParseDotEnv(r io.Reader, name string) (map[string]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := make(map[string]string)
+	if err := parseDotEnv(string(data), name, vars, true); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+func loadDotEnv(files []string, override bool) error {
+	vars := make(map[string]string)
+	for _, file := range files {
+		if err := readDotEnvFile(file, vars, override); err != nil {
+			return err
+		}
+	}
+
+	for key, value := range vars {
+		if _, set := os.LookupEnv(key); set && !override {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("envutils: setting %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func readDotEnvFile(file string, vars map[string]string, override bool) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("envutils: reading %s: %w", file, err)
+	}
+	return parseDotEnv(string(data), file, vars, override)
+}
+
+// parseDotEnv parses data into vars. Interpolation sees the variables parsed so far;
+// unless override is set, variables of the process environment take precedence over them.
+func parseDotEnv(data, file string, vars map[string]string, override bool) error {
+	lookup := func(key string) (string, bool) {
+		if v, ok := os.LookupEnv(key); ok && !override {
+			return v, true
+		}
+		if v, ok := vars[key]; ok {
+			return v, true
+		}
+		return os.LookupEnv(key)
+	}
+
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		fail := func(format string, args ...any) error {
+			return &DotEnvError{File: file, Line: lineNo, Msg: fmt.Sprintf(format, args...)}
+		}
+
+		line := strings.TrimSpace(lines[i])
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "export "); ok {
+			line = strings.TrimSpace(rest)
+		}
+
+		key, rest, ok := strings.Cut(line, "=")
+		if !ok {
+			return fail("expected KEY=VALUE, got %q", line)
+		}
+		key = strings.TrimSpace(key)
+		if !isValidKey(key) {
+			return fail("invalid variable name %q", key)
+		}
+		rest = strings.TrimLeft(rest, " \t")
+
+		var value string
+		switch {
+		case rest != "" && (rest[0] == '\'' || rest[0] == '"'):
+			quote := rest[0]
+			body := rest[1:]
+			end := closingQuote(body, quote)
+			for end < 0 {
+				if i+1 >= len(lines) {
+					return fail("unterminated %c-quoted value for %s", quote, key)
+				}
+				i++
+				body += "\n" + lines[i]
+				end = closingQuote(body, quote)
+			}
+
+			trailing := strings.TrimSpace(body[end+1:])
+			if trailing != "" && trailing[0] != '#' {
+				return fail("unexpected characters after quoted value for %s: %q", key, trailing)
+			}
+
+			value = body[:end]
+			if quote == '"' {
+				expanded, err := expand(value, true, lookup)
+				if err != nil {
+					return fail("%s: %v", key, err)
+				}
+				value = expanded
+			}
+		default:
+			if idx := strings.Index(rest, " #"); idx >= 0 {
+				rest = rest[:idx]
+			}
+			expanded, err := expand(strings.TrimSpace(rest), false, lookup)
+			if err != nil {
+				return fail("%s: %v", key, err)
+			}
+			value = expanded
+		}
+
+		vars[key] = value
+	}
+
+	return nil
+}
+
+// closingQuote returns the index of the closing quote in s, or -1. Double quotes can be escaped with a backslash.
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// expand resolves ${VAR}, ${VAR:-default} and $VAR references, and backslash escapes when escapes is set.
+func expand(s string, escapes bool, lookup func(string) (string, bool)) (string, error) {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escapes && c == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '"', '\\', '$':
+				b.WriteByte(s[i])
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(s[i])
+			}
+		case c == '$' && i+1 < len(s) && s[i+1] == '{':
+			end := closingBrace(s[i+2:])
+			if end < 0 {
+				return "", fmt.Errorf("unterminated ${ reference")
+			}
+			expr := s[i+2 : i+2+end]
+			i += 2 + end
+
+			name, def, hasDef := strings.Cut(expr, ":-")
+			if !isValidKey(name) {
+				return "", fmt.Errorf("invalid variable reference ${%s}", expr)
+			}
+			value, ok := lookup(name)
+			if (!ok || value == "") && hasDef {
+				expanded, err := expand(def, false, lookup)
+				if err != nil {
+					return "", err
+				}
+				value = expanded
+			}
+			b.WriteString(value)
+		case c == '$' && i+1 < len(s) && isKeyStart(s[i+1]):
+			j := i + 1
+			for j < len(s) && isNameChar(s[j]) {
+				j++
+			}
+			value, _ := lookup(s[i+1 : j])
+			b.WriteString(value)
+			i = j - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// closingBrace returns the index of the brace closing a ${ reference in s, skipping nested references, or -1.
+func closingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+func isValidKey(key string) bool {
+	if key == "" || !isKeyStart(key[0]) {
+		return false
+	}
+	for i := 1; i < len(key); i++ {
+		if !isKeyChar(key[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isKeyStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// isKeyChar reports whether c may appear in a key or a ${VAR} reference, which allow dots.
+func isKeyChar(c byte) bool {
+	return isNameChar(c) || c == '.'
+}
+
+// isNameChar reports whether c may appear in a bare $VAR reference, which ends at the first dot.
+func isNameChar(c byte) bool {
+	return isKeyStart(c) || ('0' <= c && c <= '9')
+}
@@ -0,0 +1,139 @@
+package envutils
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeDotEnv(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestParseDotEnv(t *testing.T) {
+	t.Setenv("DOTENV_HOME", "/home/app")
+
+	tests := []struct {
+		name     string
+		content  string
+		expected map[string]string
+	}{
+		{"Unquoted", "KEY= value ", map[string]string{"KEY": "value"}},
+		{"Empty", "KEY=", map[string]string{"KEY": ""}},
+		{"Comments and blank lines", "# comment\n\n  # indented\nKEY=value # inline", map[string]string{"KEY": "value"}},
+		{"Hash without space", "KEY=a#b", map[string]string{"KEY": "a#b"}},
+		{"Export prefix", "export KEY=value", map[string]string{"KEY": "value"}},
+		{"Single-quoted is literal", `KEY='a ${DOTENV_HOME} \n # b'`, map[string]string{"KEY": `a ${DOTENV_HOME} \n # b`}},
+		{"Double-quoted escapes", `KEY="a\tb\n\"c\" \\ \$HOME" # comment`, map[string]string{"KEY": "a\tb\n\"c\" \\ $HOME"}},
+		{"Multi-line", "KEY=\"line1\nline2\"\nNEXT='a\nb'", map[string]string{"KEY": "line1\nline2", "NEXT": "a\nb"}},
+		{"CRLF", "A=1\r\nB=2\r\n", map[string]string{"A": "1", "B": "2"}},
+		{"Interpolation", "DIR=${DOTENV_HOME}/data\nFILE=\"$DIR/db\"", map[string]string{"DIR": "/home/app/data", "FILE": "/home/app/data/db"}},
+		{"Default", "A=${DOTENV_UNSET:-fallback}\nB=${DOTENV_HOME:-fallback}", map[string]string{"A": "fallback", "B": "/home/app"}},
+		{"Nested default", "A=${DOTENV_UNSET:-${DOTENV_HOME}/x}", map[string]string{"A": "/home/app/x"}},
+		{"Unknown is empty", "A=[${DOTENV_UNSET}]", map[string]string{"A": "[]"}},
+		{"Bare reference ends at dot", "HOST=example\nURL=$HOST.com", map[string]string{"HOST": "example", "URL": "example.com"}},
+		{"Dotted key", "app.name=orders\nA=${app.name}-$app.name", map[string]string{"app.name": "orders", "A": "orders-.name"}},
+		{"Lone dollar", "A=$ 5", map[string]string{"A": "$ 5"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars, err := ParseDotEnv(strings.NewReader(tt.content), ".env")
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, vars)
+		})
+	}
+}
+
+func TestParseDotEnv_SyntaxErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		line    int
+		msg     string
+	}{
+		{"Missing equals", "A=1\nINVALID", 2, "expected KEY=VALUE"},
+		{"Invalid key", "1A=1", 1, "invalid variable name"},
+		{"Unterminated quote", "A=1\nB=\"open\nstill open", 2, "unterminated"},
+		{"Trailing characters", "A='x' y", 1, "unexpected characters"},
+		{"Unterminated reference", "A=${B", 1, "unterminated ${"},
+		{"Invalid reference", "A=${B C}", 1, "invalid variable reference"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDotEnv(strings.NewReader(tt.content), "app.env")
+
+			var de *DotEnvError
+			require.ErrorAs(t, err, &de)
+			assert.Equal(t, "app.env", de.File)
+			assert.Equal(t, tt.line, de.Line)
+			assert.Contains(t, de.Msg, tt.msg)
+		})
+	}
+}
+
+func TestLoadDotEnv(t *testing.T) {
+	t.Setenv("DOTENV_SET", "process")
+	base := writeDotEnv(t, "DOTENV_A=base\nDOTENV_B=base\nDOTENV_SET=file")
+	local := writeDotEnv(t, "DOTENV_B=local\nDOTENV_C=${DOTENV_SET}-${DOTENV_A}")
+	for _, k := range []string{"DOTENV_A", "DOTENV_B", "DOTENV_C"} {
+		t.Setenv(k, "")
+		require.NoError(t, os.Unsetenv(k))
+	}
+
+	require.NoError(t, LoadDotEnv(base, local))
+
+	assert.Equal(t, "base", os.Getenv("DOTENV_A"))
+	assert.Equal(t, "local", os.Getenv("DOTENV_B"))
+	assert.Equal(t, "process", os.Getenv("DOTENV_SET"))
+	assert.Equal(t, "process-base", os.Getenv("DOTENV_C"))
+}
+
+func TestOverloadDotEnv(t *testing.T) {
+	t.Setenv("DOTENV_SET", "process")
+	t.Setenv("DOTENV_C", "")
+	path := writeDotEnv(t, "DOTENV_SET=file\nDOTENV_C=${DOTENV_SET}")
+
+	require.NoError(t, OverloadDotEnv(path))
+
+	assert.Equal(t, "file", os.Getenv("DOTENV_SET"))
+	assert.Equal(t, "file", os.Getenv("DOTENV_C"))
+}
+
+func TestReadDotEnv(t *testing.T) {
+	t.Setenv("DOTENV_SET", "process")
+	path := writeDotEnv(t, "DOTENV_SET=file")
+
+	vars, err := ReadDotEnv(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"DOTENV_SET": "file"}, vars)
+	assert.Equal(t, "process", os.Getenv("DOTENV_SET"))
+}
+
+func TestLoadDotEnv_Errors(t *testing.T) {
+	t.Run("Missing file", func(t *testing.T) {
+		err := LoadDotEnv(filepath.Join(t.TempDir(), "missing.env"))
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("Syntax error reports the file", func(t *testing.T) {
+		path := writeDotEnv(t, "A=1\n\nBROKEN")
+		err := LoadDotEnv(path)
+
+		var de *DotEnvError
+		require.ErrorAs(t, err, &de)
+		assert.Equal(t, path, de.File)
+		assert.Equal(t, 3, de.Line)
+	})
+}
@@ -2,19 +2,36 @@
 package envutils
 
 import (
+	"net/url"
 	"os"
-	"strconv"
-	"strings"
 	"time"
 )
 
 // GetEnvWithDefault retrieves the value of the environment variable named by the key.
-// If the variable is present in the environment, it returns the trimmed value. Otherwise, it returns the default value.
+// If the variable is present in the environment, it returns the trimmed value. Otherwise, it returns the trimmed
+// contents of the file named by the key with the _FILE suffix if set, or the default value.
+// Encrypted values are never returned: the getters cannot decrypt them, so they return the default value, and
+// the strict getters an error wrapping ErrNoKey. Use Load with WithDecryption to read encrypted values.
+// Use GetEnvStrict to detect unreadable files and conflicting variables.
 func GetEnvWithDefault(key string, defaultVal string) string {
-	if value, ok := os.LookupEnv(key); ok {
-		return strings.TrimSpace(value)
+	value, ok, _, err := lookupEnv(key)
+	if !ok || err != nil {
+		return defaultVal
+	}
+	return value
+}
+
+// GetEnvStrict is like GetEnvWithDefault, but returns a *VarError when the file named by the key with the _FILE suffix
+// cannot be read, when both the variable and its _FILE variant are set, or when the value is encrypted.
+func GetEnvStrict(key string, defaultVal string) (string, error) {
+	value, ok, fromFile, err := lookupEnv(key)
+	if err != nil {
+		return defaultVal, newVarError(key, "", "string", fromFile, err)
 	}
-	return defaultVal
+	if !ok {
+		return defaultVal, nil
+	}
+	return value, nil
 }
 
 // GetEnv retrieves the value of the environment variable named by the key.
@@ -25,47 +42,126 @@ func GetEnv(key string) string {
 
 // GetEnvAsBool retrieves the value of the environment variable named by the key and parses it as a boolean.
 // If the variable is not present or cannot be parsed as a boolean, it returns the default value.
+// Use GetEnvAsBoolStrict to detect malformed values.
 func GetEnvAsBool(key string, defaultVal bool) bool {
-	b, err := strconv.ParseBool(GetEnv(key))
-	if err != nil {
-		return defaultVal
-	}
+	b, _ := GetEnvAsBoolStrict(key, defaultVal)
 	return b
 }
 
 // GetEnvAsInt retrieves the value of the environment variable named by the key and parses it as an integer.
 // If the variable is not present or cannot be parsed as an integer, it returns the default value.
+// Use GetEnvAsIntStrict to detect malformed values.
 func GetEnvAsInt(key string, defaultVal int) int {
-	i, err := strconv.Atoi(GetEnv(key))
-	if err != nil {
-		return defaultVal
-	}
+	i, _ := GetEnvAsIntStrict(key, defaultVal)
 	return i
 }
 
 // GetEnvAsInt32 retrieves the value of the environment variable named by the key and parses it as an int32.
 // If the variable is not present or cannot be parsed as an int32, it returns the default value.
+// Use GetEnvAsInt32Strict to detect malformed values.
 func GetEnvAsInt32(key string, defaultVal int32) int32 {
-	i, err := strconv.ParseInt(GetEnv(key), 10, 32)
-	if err != nil {
-		return defaultVal
+	i, _ := GetEnvAsInt32Strict(key, defaultVal)
+	return i
+}
+
+// GetEnvAsInt64 retrieves the value of the environment variable named by the key and parses it as an int64.
+// If the variable is not present or cannot be parsed as an int64, it returns the default value.
+// Use GetEnvAsInt64Strict to detect malformed values.
+func GetEnvAsInt64(key string, defaultVal int64) int64 {
+	i, _ := GetEnvAsInt64Strict(key, defaultVal)
+	return i
+}
+
+// GetEnvAsUint64 retrieves the value of the environment variable named by the key and parses it as a uint64.
+// If the variable is not present or cannot be parsed as a uint64, it returns the default value.
+// Use GetEnvAsUint64Strict to detect malformed values.
+func GetEnvAsUint64(key string, defaultVal uint64) uint64 {
+	u, _ := GetEnvAsUint64Strict(key, defaultVal)
+	return u
+}
+
+// GetEnvAsFloat64 retrieves the value of the environment variable named by the key and parses it as a float64.
+// If the variable is not present or cannot be parsed as a float64, it returns the default value.
+// Use GetEnvAsFloat64Strict to detect malformed values.
+func GetEnvAsFloat64(key string, defaultVal float64) float64 {
+	f, _ := GetEnvAsFloat64Strict(key, defaultVal)
+	return f
+}
+
+// GetEnvAsSlice retrieves the value of the environment variable named by the key as a comma-separated list,
+// with elements trimmed and empty ones dropped. If the variable is not present, it returns the default value.
+func GetEnvAsSlice(key string, defaultVal []string) []string {
+	s, _ := GetEnvAsSliceStrict(key, defaultVal)
+	return s
+}
+
+// GetEnvAsMap retrieves the value of the environment variable named by the key as comma-separated key=value pairs.
+// If the variable is not present or a pair has no =, it returns the default value.
+// Use GetEnvAsMapStrict to detect malformed values.
+func GetEnvAsMap(key string, defaultVal map[string]string) map[string]string {
+	m, _ := GetEnvAsMapStrict(key, defaultVal)
+	return m
+}
+
+// GetEnvAsURL retrieves the value of the environment variable named by the key and parses it as an absolute URL.
+// If the variable is not present or cannot be parsed as an absolute URL, it returns the default value.
+// Use GetEnvAsURLStrict to detect malformed values.
+func GetEnvAsURL(key string, defaultVal *url.URL) *url.URL {
+	u, _ := GetEnvAsURLStrict(key, defaultVal)
+	return u
+}
+
+// GetEnvAsByteSize retrieves the value of the environment variable named by the key and parses it as a byte size,
+// such as "512MiB". If the variable is not present or cannot be parsed, it returns the default value.
+// Use GetEnvAsByteSizeStrict to detect malformed values.
+func GetEnvAsByteSize(key string, defaultVal ByteSize) ByteSize {
+	b, _ := GetEnvAsByteSizeStrict(key, defaultVal)
+	return b
+}
+
+// GetEnvAsLocation retrieves the value of the environment variable named by the key and loads it as a time zone,
+// such as "Europe/Paris". If the variable is not present or the location cannot be loaded, it returns the default value.
+// Use GetEnvAsLocationStrict to detect unknown locations.
+func GetEnvAsLocation(key string, defaultVal *time.Location) *time.Location {
+	loc, _ := GetEnvAsLocationStrict(key, defaultVal)
+	return loc
+}
+
+// GetEnvAsEnum retrieves the value of the environment variable named by the key, which must be one of the allowed values.
+// If the variable is not present or not allowed, it returns the default value.
+// Use GetEnvAsEnumStrict to detect values that are not allowed.
+func GetEnvAsEnum[T ~string](key string, defaultVal T, allowed ...T) T {
+	v, _ := GetEnvAsEnumStrict(key, defaultVal, allowed...)
+	return v
+}
+
+// lookupEnv looks up the variable, or its _FILE variant, in the process environment. Encrypted values cannot be
+// decrypted without a Cipher, so they fail with ErrNoKey instead of being returned as is.
+func lookupEnv(key string) (value string, ok, fromFile bool, err error) {
+	value, ok, fromFile, err = lookupWithFile(os.LookupEnv, key)
+	if err == nil && ok && IsEncrypted(value) {
+		return "", ok, fromFile, ErrNoKey
 	}
-	return int32(i)
+	return value, ok, fromFile, err
 }
 
 // GetEnvAsDuration retrieves an environment variable as a time.Duration.
-// If the variable is not set or cannot be parsed as a duration, it returns the default value.
+// If the variable is not set, it parses the default value. It returns a *VarError if the value cannot be parsed as a duration.
+// GetEnvAsDurationStrict takes the default value as a time.Duration.
 func GetEnvAsDuration(key string, defaultVal string) (time.Duration, error) {
-	// Retrieve the environment variable
-	s, ok := os.LookupEnv(key)
+	// Retrieve the environment variable, or the contents of its _FILE variant
+	s, ok, fromFile, err := lookupEnv(key)
+	if err != nil {
+		return 0, newVarError(key, "", "duration", fromFile, err)
+	}
 	if !ok {
 		s = defaultVal
 	}
 
-	// Parse the duration
+	// Parse the duration, redacting values read from files
 	d, err := time.ParseDuration(s)
 	if err != nil {
-		return 0, err
+		return 0, newVarError(key, s, "duration", fromFile, err)
 	}
 
 	return d, nil
@@ -0,0 +1,88 @@
+package envutils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrMissing is wrapped by the VarError of a required variable that is not set.
+var ErrMissing = errors.New("variable is not set")
+
+// redacted replaces secret values in error messages.
+const redacted = "[REDACTED]"
+
+// VarError describes a missing, unreadable or unparsable environment variable.
+type VarError struct {
+	// Key is the name of the variable.
+	Key string
+	// Value is the raw value that failed to parse.
+	Value string
+	// Expected is the type the value should have been parsed into.
+	Expected string
+	// Secret hides the value, and any parser message that could contain it, from Error.
+	Secret bool
+	// Err is the underlying error, ErrMissing for missing variables and ErrFileConflict when
+	// both the variable and its _FILE variant are set.
+	Err error
+}
+
+func newVarError(key, value, expected string, secret bool, err error) *VarError {
+	// strconv errors repeat the input; keep only the reason.
+	if ne, ok := err.(*strconv.NumError); ok {
+		err = ne.Err
+	}
+	return &VarError{Key: key, Value: value, Expected: expected, Secret: secret, Err: err}
+}
+
+func missingVarError(key, expected string, secret bool) *VarError {
+	return &VarError{Key: key, Expected: expected, Secret: secret, Err: ErrMissing}
+}
+
+func (e *VarError) Error() string {
+	if errors.Is(e.Err, ErrMissing) {
+		return fmt.Sprintf("%s: required variable is not set (expected %s)", e.Key, e.Expected)
+	}
+	if e.Value == "" {
+		return fmt.Sprintf("%s: %v (expected %s)", e.Key, e.Err, e.Expected)
+	}
+	if e.Secret {
+		return fmt.Sprintf("%s: invalid value %s (expected %s)", e.Key, redacted, e.Expected)
+	}
+	return fmt.Sprintf("%s: invalid value %q (expected %s): %v", e.Key, e.Value, e.Expected, e.Err)
+}
+
+func (e *VarError) Unwrap() error {
+	return e.Err
+}
+
+// Errors aggregates every missing or invalid variable, so they can be reported at once.
+type Errors []*VarError
+
+func (e Errors) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "envutils: %d invalid environment variable(s):", len(e))
+	for _, ve := range e {
+		b.WriteString("\n  - ")
+		b.WriteString(ve.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns the individual errors, for errors.Is and errors.As.
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, ve := range e {
+		errs[i] = ve
+	}
+	return errs
+}
+
+// errOrNil returns nil for an empty Errors, avoiding a non-nil error interface holding a nil slice.
+func (e Errors) errOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
@@ -0,0 +1,37 @@
+package envutils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FileSuffix is appended to a variable name to name the variable holding the path of a file with its value,
+// as used for Docker and Kubernetes secrets: DB_PASSWORD_FILE=/run/secrets/db_password.
+const FileSuffix = "_FILE"
+
+// ErrFileConflict is wrapped by the VarError of a variable set both directly and through its _FILE variant.
+var ErrFileConflict = errors.New("both variable and its _FILE variant are set")
+
+// lookupWithFile looks up the trimmed value of the variable. When the variable is not set,
+// the trimmed contents of the file named by its _FILE variant are returned instead, with fromFile set.
+// An empty variable counts as unset; setting both variables with non-empty values is an error.
+func lookupWithFile(lookup func(key string) (string, bool), key string) (value string, ok, fromFile bool, err error) {
+	value, ok = lookup(key)
+	value = strings.TrimSpace(value)
+
+	path, hasFile := lookup(key + FileSuffix)
+	path = strings.TrimSpace(path)
+	if !hasFile || path == "" {
+		return value, ok, false, nil
+	}
+	if value != "" {
+		return "", false, false, fmt.Errorf("%w: %s", ErrFileConflict, key+FileSuffix)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, true, fmt.Errorf("reading %s: %w", key+FileSuffix, err)
+	}
+	return strings.TrimSpace(string(data)), true, true, nil
+}
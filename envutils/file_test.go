@@ -0,0 +1,134 @@
+package envutils
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSecret(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestGetEnv_File(t *testing.T) {
+	t.Setenv("FILE_PASSWORD_FILE", writeSecret(t, "  s3cr3t\n"))
+	t.Setenv("FILE_PORT_FILE", writeSecret(t, "8080\n"))
+	t.Setenv("FILE_DEBUG_FILE", writeSecret(t, "true"))
+	t.Setenv("FILE_TIMEOUT_FILE", writeSecret(t, "5s"))
+
+	assert.Equal(t, "s3cr3t", GetEnv("FILE_PASSWORD"))
+	assert.Equal(t, 8080, GetEnvAsInt("FILE_PORT", 0))
+	assert.Equal(t, int32(8080), GetEnvAsInt32("FILE_PORT", 0))
+	assert.True(t, GetEnvAsBool("FILE_DEBUG", false))
+
+	d, err := GetEnvAsDuration("FILE_TIMEOUT", "1s")
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, d)
+
+	s, err := GetEnvStrict("FILE_PASSWORD", "default")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", s)
+}
+
+func TestGetEnv_FileEmptyVariable(t *testing.T) {
+	t.Setenv("FILE_PASSWORD", "")
+	t.Setenv("FILE_PASSWORD_FILE", writeSecret(t, "s3cr3t"))
+
+	assert.Equal(t, "s3cr3t", GetEnv("FILE_PASSWORD"))
+}
+
+func TestGetEnv_FileConflict(t *testing.T) {
+	t.Setenv("FILE_PORT", "9090")
+	t.Setenv("FILE_PORT_FILE", writeSecret(t, "8080"))
+
+	assert.Equal(t, "default", GetEnvWithDefault("FILE_PORT", "default"))
+
+	_, err := GetEnvStrict("FILE_PORT", "default")
+	assert.ErrorIs(t, err, ErrFileConflict)
+
+	i, err := GetEnvAsIntStrict("FILE_PORT", 1)
+	assert.ErrorIs(t, err, ErrFileConflict)
+	assert.Equal(t, 1, i)
+	assert.EqualError(t, err, "FILE_PORT: both variable and its _FILE variant are set: FILE_PORT_FILE (expected int)")
+}
+
+func TestGetEnv_FileUnreadable(t *testing.T) {
+	t.Setenv("FILE_PORT_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	_, err := GetEnvAsIntStrict("FILE_PORT", 1)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = GetEnvAsDuration("FILE_PORT", "1s")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestGetEnvStrict_FileValueRedacted(t *testing.T) {
+	t.Setenv("FILE_PORT_FILE", writeSecret(t, "s3cr3t-value"))
+
+	_, err := GetEnvAsIntStrict("FILE_PORT", 0)
+
+	var ve *VarError
+	require.ErrorAs(t, err, &ve)
+	assert.True(t, ve.Secret)
+	assert.NotContains(t, err.Error(), "s3cr3t-value")
+}
+
+func TestGetEnvAsDuration_FileValueRedacted(t *testing.T) {
+	t.Setenv("FILE_TIMEOUT_FILE", writeSecret(t, "hunter2"))
+
+	_, err := GetEnvAsDuration("FILE_TIMEOUT", "1s")
+
+	var ve *VarError
+	require.ErrorAs(t, err, &ve)
+	assert.True(t, ve.Secret)
+	assert.NotContains(t, err.Error(), "hunter2")
+}
+
+func TestCollector_File(t *testing.T) {
+	t.Setenv("FILE_PASSWORD_FILE", writeSecret(t, "s3cr3t"))
+	t.Setenv("FILE_USER", "app")
+	t.Setenv("FILE_USER_FILE", writeSecret(t, "other"))
+
+	c := NewCollector()
+	assert.Equal(t, "s3cr3t", c.RequiredString("FILE_PASSWORD"))
+	c.RequiredString("FILE_USER")
+
+	var errs Errors
+	require.ErrorAs(t, c.Err(), &errs)
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], ErrFileConflict)
+}
+
+func TestLoad_File(t *testing.T) {
+	t.Setenv("NAME_FILE", writeSecret(t, "orders\n"))
+	t.Setenv("DB_PORT_FILE", writeSecret(t, "not-a-port"))
+
+	var cfg testConfig
+	err := Load(&cfg)
+
+	var errs Errors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "orders", cfg.Name)
+	assert.Equal(t, "DB_PORT", errs[0].Key)
+	assert.True(t, errs[0].Secret)
+	assert.NotContains(t, err.Error(), "not-a-port")
+}
+
+func TestLoad_FileConflict(t *testing.T) {
+	t.Setenv("NAME", "orders")
+	t.Setenv("NAME_FILE", writeSecret(t, "orders"))
+
+	var cfg testConfig
+	err := Load(&cfg)
+
+	assert.ErrorIs(t, err, ErrFileConflict)
+}
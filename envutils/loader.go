@@ -0,0 +1,325 @@
+package envutils
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Struct tags understood by Load.
+const (
+	tagEnv         = "env"
+	tagDefault     = "default"
+	tagRequired    = "required"
+	tagSecret      = "secret"
+	tagPrefix      = "envPrefix"
+	tagSeparator   = "envSeparator"
+	tagKVSeparator = "envKeyValSeparator"
+)
+
+// Default separators for slice and map fields.
+const (
+	DefaultSeparator   = ","
+	DefaultKVSeparator = "="
+)
+
+// LoadOption configures Load.
+type LoadOption func(*loader)
+
+// WithPrefix prepends a prefix to every variable name, e.g. "APP_" to read PORT from APP_PORT.
+func WithPrefix(prefix string) LoadOption {
+	return func(l *loader) {
+		l.prefix = prefix
+	}
+}
+
+// WithLookuper reads values from l instead of the process environment, e.g. a Merged source
+// or, in tests, a MapSource.
+func WithLookuper(l Lookuper) LoadOption {
+	return func(ld *loader) {
+		ld.lookup = l.Lookup
+		ld.origin = originFunc(l)
+	}
+}
+
+type loader struct {
+	prefix string
+	lookup func(key string) (string, bool)
+	// origin names the source of a key, for Describe.
+	origin func(key string) string
+	cipher *Cipher
+}
+
+// field is a struct field bound to an environment variable.
+type field struct {
+	name       string
+	key        string
+	value      reflect.Value
+	def        string
+	hasDefault bool
+	required   bool
+	secret     bool
+	sep        string
+	kvSep      string
+	// optional is the innermost nil pointer to a nested struct the field belongs to, if any.
+	optional *optional
+}
+
+// optional is a nil pointer to a nested struct. It is only allocated when one of its variables is set.
+type optional struct {
+	ptr     reflect.Value
+	value   reflect.Value
+	parent  *optional
+	present bool
+}
+
+// markPresent marks the nested struct and its enclosing ones as present.
+func (o *optional) markPresent() {
+	for ; o != nil && !o.present; o = o.parent {
+		o.present = true
+	}
+}
+
+// skipped reports whether the field belongs to a nested struct that stays nil.
+func (f field) skipped() bool {
+	return f.optional != nil && !f.optional.present
+}
+
+// Load fills the struct pointed to by cfg from environment variables, following its field tags:
+//
+//	Port    int            `env:"PORT" default:"8080"`
+//	DSN     string         `env:"DSN" required:"true"`
+//	Hosts   []string       `env:"HOSTS" envSeparator:";"`
+//	Labels  map[string]int `env:"LABELS" envSeparator:"," envKeyValSeparator:"="`
+//	Timeout *time.Duration `env:"TIMEOUT"`
+//	Token   string         `env:"TOKEN" secret:"true"`
+//	DB      DBConfig       `envPrefix:"DB_"`
+//
+// Supported field types are strings, booleans, integers, unsigned integers, floats, time.Duration,
+// types implementing encoding.TextUnmarshaler, and slices and maps of those. Pointer fields stay nil
+// when the variable is unset and has no default. Struct fields without an env tag are loaded recursively,
+// with their envPrefix prepended to the names of their variables; nil pointers to structs are only allocated
+// when one of their variables is set, otherwise their defaults and required variables are ignored.
+// Recursive structs are rejected. Values are trimmed, and empty values
+// are treated as unset. When a variable is unset, the trimmed contents of the file named by its _FILE variant
+// are used instead, e.g. DSN_FILE=/run/secrets/dsn; setting both is an error. Fields tagged secret:"true"
+// and values read from files have their value redacted from errors. Encrypted values, see EncryptedPrefix,
+// are decrypted with the Cipher given with WithDecryption, and redacted from errors too.
+//
+// Every missing or invalid variable is reported in the returned error, of type Errors.
+func Load(cfg any, opts ...LoadOption) error {
+	l, fields, err := prepare("Load", cfg, opts)
+	if err != nil {
+		return err
+	}
+
+	l.markOptionals(fields)
+
+	var errs Errors
+	for _, f := range fields {
+		if f.skipped() {
+			continue
+		}
+		if err := l.load(f); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, f := range fields {
+		for o := f.optional; o != nil && o.present; o = o.parent {
+			if o.ptr.IsNil() {
+				o.ptr.Set(o.value)
+			}
+		}
+	}
+	return errs.errOrNil()
+}
+
+// Keys returns the names of the variables Load reads for cfg, in field order, e.g. to report
+// their origin with Merged.Origins.
+func Keys(cfg any, opts ...LoadOption) ([]string, error) {
+	_, fields, err := prepare("Keys", cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, len(fields))
+	for i, f := range fields {
+		keys[i] = f.key
+	}
+	return keys, nil
+}
+
+// prepare validates the target of fn, applies the options and collects the fields of cfg.
+func prepare(fn string, cfg any, opts []LoadOption) (*loader, []field, error) {
+	rv := reflect.ValueOf(cfg)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("envutils: %s expects a non-nil pointer to a struct, got %T", fn, cfg)
+	}
+
+	l := &loader{lookup: os.LookupEnv, origin: func(string) string { return "env" }}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	fields, err := collectFields(rv.Elem(), "", l.prefix, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return l, fields, nil
+}
+
+// load resolves the raw value of a field and decodes it into the field.
+func (l *loader) load(f field) *VarError {
+	raw, ok, fromFile, err := lookupWithFile(l.lookup, f.key)
+	secret := f.secret || fromFile || IsEncrypted(raw)
+	if err == nil && IsEncrypted(raw) {
+		raw, err = l.decrypt(raw)
+	}
+	if err != nil {
+		return newVarError(f.key, "", typeName(f.value.Type()), secret, err)
+	}
+	if !ok || raw == "" {
+		switch {
+		case f.hasDefault:
+			raw = f.def
+		case f.required:
+			return missingVarError(f.key, typeName(f.value.Type()), secret)
+		default:
+			return nil
+		}
+	}
+
+	if err := decode(f.value, raw, f.sep, f.kvSep); err != nil {
+		return newVarError(f.key, raw, typeName(f.value.Type()), secret, err)
+	}
+	return nil
+}
+
+// markOptionals marks the nested structs behind nil pointers that have at least one variable set.
+func (l *loader) markOptionals(fields []field) {
+	for _, f := range fields {
+		if f.skipped() && l.isSet(f.key) {
+			f.optional.markPresent()
+		}
+	}
+}
+
+// isSet reports whether a variable, or its _FILE variant, is set to a non-empty value. Invalid combinations
+// count as set, so that they are reported.
+func (l *loader) isSet(key string) bool {
+	raw, ok, _, err := lookupWithFile(l.lookup, key)
+	return err != nil || ok && raw != ""
+}
+
+// decrypt decrypts an encrypted value, failing with ErrNoKey without cipher.
+func (l *loader) decrypt(raw string) (string, error) {
+	if l.cipher == nil {
+		return "", ErrNoKey
+	}
+	plaintext, err := l.cipher.Decrypt(raw)
+	return strings.TrimSpace(plaintext), err
+}
+
+// typeName returns the type name reported in errors, without pointer indirection.
+func typeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.String()
+}
+
+// collectFields walks a struct and returns the fields bound to environment variables.
+// Fields are named by their path from the root struct, e.g. DB.Host. visiting holds the types of the
+// enclosing structs, to detect recursion.
+func collectFields(v reflect.Value, path, prefix string, opt *optional, visiting []reflect.Type) ([]field, error) {
+	var fields []field
+	t := v.Type()
+	visiting = append(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+
+		key, hasKey := sf.Tag.Lookup(tagEnv)
+		if !hasKey {
+			nested, nestedOpt, ok := nestedStruct(fv, opt)
+			if !ok {
+				continue
+			}
+			if slices.Contains(visiting, nested.Type()) {
+				return nil, fmt.Errorf("envutils: recursive struct %s in field %s", nested.Type(), path+sf.Name)
+			}
+			sub, err := collectFields(nested, path+sf.Name+".", prefix+sf.Tag.Get(tagPrefix), nestedOpt, visiting)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, sub...)
+			continue
+		}
+
+		if key == "" {
+			return nil, fmt.Errorf("envutils: empty env tag on field %s.%s", t.Name(), sf.Name)
+		}
+
+		def, hasDefault := sf.Tag.Lookup(tagDefault)
+		f := field{
+			name:       path + sf.Name,
+			key:        prefix + key,
+			value:      fv,
+			def:        def,
+			hasDefault: hasDefault,
+			required:   sf.Tag.Get(tagRequired) == "true",
+			secret:     sf.Tag.Get(tagSecret) == "true",
+			sep:        tagOr(sf, tagSeparator, DefaultSeparator),
+			kvSep:      tagOr(sf, tagKVSeparator, DefaultKVSeparator),
+			optional:   opt,
+		}
+		fields = append(fields, f)
+	}
+
+	return fields, nil
+}
+
+// nestedStruct returns the struct to recurse into for struct and pointer-to-struct fields. The struct of a
+// nil pointer is allocated apart, and returned with its optional, to be assigned only if one of its variables is set.
+func nestedStruct(fv reflect.Value, opt *optional) (reflect.Value, *optional, bool) {
+	switch {
+	case isNestable(fv.Type()):
+		return fv, opt, true
+	case fv.Kind() == reflect.Pointer && isNestable(fv.Type().Elem()):
+		if !fv.IsNil() {
+			return fv.Elem(), opt, true
+		}
+		nested := &optional{ptr: fv, value: reflect.New(fv.Type().Elem()), parent: opt}
+		return nested.value.Elem(), nested, true
+	default:
+		return reflect.Value{}, nil, false
+	}
+}
+
+// isNestable reports whether a type is a struct to load recursively: a struct with exported fields
+// that is not decoded as a single value, like url.URL or types implementing encoding.TextUnmarshaler.
+func isNestable(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == urlType || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
+
+func tagOr(sf reflect.StructField, tag, fallback string) string {
+	if v, ok := sf.Tag.Lookup(tag); ok && v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,220 @@
+package envutils
+
+import (
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type dbConfig struct {
+	Host string `env:"HOST" default:"localhost"`
+	Port int    `env:"PORT" default:"5432"`
+}
+
+type level string
+
+func (l *level) UnmarshalText(text []byte) error {
+	*l = level(strings.ToUpper(string(text)))
+	return nil
+}
+
+type testConfig struct {
+	Name     string            `env:"NAME" required:"true"`
+	Port     int               `env:"PORT" default:"8080"`
+	Debug    bool              `env:"DEBUG"`
+	Ratio    float64           `env:"RATIO" default:"0.5"`
+	MaxConns uint16            `env:"MAX_CONNS" default:"10"`
+	Small    int8              `env:"SMALL"`
+	Timeout  time.Duration     `env:"TIMEOUT" default:"30s"`
+	Hosts    []string          `env:"HOSTS" envSeparator:";"`
+	Ports    []int             `env:"PORTS"`
+	Labels   map[string]string `env:"LABELS"`
+	Weights  map[string]int    `env:"WEIGHTS" envKeyValSeparator:":"`
+	Level    level             `env:"LEVEL" default:"info"`
+	IP       net.IP            `env:"IP"`
+	Optional *int              `env:"OPTIONAL"`
+	Deadline *time.Duration    `env:"DEADLINE"`
+	DB       dbConfig          `envPrefix:"DB_"`
+	Replica  *dbConfig         `envPrefix:"REPLICA_"`
+	ignored  string            `env:"IGNORED"`
+	Skipped  string
+}
+
+func TestLoad(t *testing.T) {
+	t.Setenv("NAME", " orders ")
+	t.Setenv("DEBUG", "true")
+	t.Setenv("HOSTS", "a.example.com; b.example.com")
+	t.Setenv("PORTS", "80,443")
+	t.Setenv("LABELS", "team=core, tier=1")
+	t.Setenv("WEIGHTS", "a:1,b:2")
+	t.Setenv("IP", "10.0.0.1")
+	t.Setenv("DEADLINE", "5s")
+	t.Setenv("DB_HOST", "db.internal")
+	t.Setenv("REPLICA_PORT", "5433")
+	t.Setenv("IGNORED", "x")
+
+	var cfg testConfig
+	require.NoError(t, Load(&cfg))
+
+	assert.Equal(t, "orders", cfg.Name)
+	assert.Equal(t, 8080, cfg.Port)
+	assert.True(t, cfg.Debug)
+	assert.Equal(t, 0.5, cfg.Ratio)
+	assert.Equal(t, uint16(10), cfg.MaxConns)
+	assert.Equal(t, 30*time.Second, cfg.Timeout)
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, cfg.Hosts)
+	assert.Equal(t, []int{80, 443}, cfg.Ports)
+	assert.Equal(t, map[string]string{"team": "core", "tier": "1"}, cfg.Labels)
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, cfg.Weights)
+	assert.Equal(t, level("INFO"), cfg.Level)
+	assert.Equal(t, "10.0.0.1", cfg.IP.String())
+	assert.Nil(t, cfg.Optional)
+	require.NotNil(t, cfg.Deadline)
+	assert.Equal(t, 5*time.Second, *cfg.Deadline)
+	assert.Equal(t, dbConfig{Host: "db.internal", Port: 5432}, cfg.DB)
+	require.NotNil(t, cfg.Replica)
+	assert.Equal(t, dbConfig{Host: "localhost", Port: 5433}, *cfg.Replica)
+	assert.Empty(t, cfg.ignored)
+}
+
+func TestLoad_WithPrefix(t *testing.T) {
+	t.Setenv("APP_NAME", "orders")
+	t.Setenv("APP_DB_PORT", "6432")
+
+	var cfg testConfig
+	require.NoError(t, Load(&cfg, WithPrefix("APP_")))
+
+	assert.Equal(t, "orders", cfg.Name)
+	assert.Equal(t, 6432, cfg.DB.Port)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Setenv("PORT", "80a")
+	t.Setenv("SMALL", "300")
+	t.Setenv("WEIGHTS", "a=1")
+
+	var cfg testConfig
+	err := Load(&cfg)
+
+	var errs Errors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 4)
+
+	assert.Equal(t, "NAME", errs[0].Key)
+	assert.ErrorIs(t, errs[0], ErrMissing)
+	assert.Equal(t, &VarError{Key: "PORT", Value: "80a", Expected: "int", Err: strconv.ErrSyntax}, errs[1])
+	assert.Equal(t, &VarError{Key: "SMALL", Value: "300", Expected: "int8", Err: strconv.ErrRange}, errs[2])
+	assert.Equal(t, "WEIGHTS", errs[3].Key)
+	assert.Equal(t, "map[string]int", errs[3].Expected)
+}
+
+func TestLoad_SecretRedacted(t *testing.T) {
+	t.Setenv("TOKEN", "s3cr3t-value")
+
+	var cfg struct {
+		Token int `env:"TOKEN" secret:"true"`
+	}
+	err := Load(&cfg)
+
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "s3cr3t-value")
+	assert.Contains(t, err.Error(), "TOKEN: invalid value [REDACTED] (expected int)")
+}
+
+func TestLoad_EmptyValueIsUnset(t *testing.T) {
+	t.Setenv("NAME", "orders")
+	t.Setenv("PORT", "  ")
+
+	var cfg testConfig
+	require.NoError(t, Load(&cfg))
+
+	assert.Equal(t, 8080, cfg.Port)
+}
+
+func TestLoad_InvalidTarget(t *testing.T) {
+	var cfg testConfig
+	tests := []struct {
+		name   string
+		target any
+	}{
+		{"Nil", nil},
+		{"Struct value", cfg},
+		{"Pointer to non-struct", new(int)},
+		{"Nil pointer", (*testConfig)(nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, Load(tt.target))
+		})
+	}
+}
+
+func TestLoad_OptionalNestedStruct(t *testing.T) {
+	type config struct {
+		Replica *struct {
+			Host string `env:"HOST" required:"true"`
+			TLS  *struct {
+				Cert string `env:"CERT"`
+			} `envPrefix:"TLS_"`
+		} `envPrefix:"REPLICA_"`
+	}
+
+	var cfg config
+	require.NoError(t, Load(&cfg))
+	assert.Nil(t, cfg.Replica, "no variable is set")
+
+	t.Setenv("REPLICA_TLS_CERT", "cert.pem")
+	err := Load(&cfg)
+	assert.ErrorContains(t, err, "REPLICA_HOST")
+	require.NotNil(t, cfg.Replica)
+	require.NotNil(t, cfg.Replica.TLS)
+	assert.Equal(t, "cert.pem", cfg.Replica.TLS.Cert)
+}
+
+func TestLoad_NestedStructsSkipped(t *testing.T) {
+	var cfg struct {
+		Endpoint url.URL
+		Since    time.Time
+		Logger   *log.Logger
+		Name     string `env:"NAME"`
+	}
+
+	keys, err := Keys(&cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"NAME"}, keys)
+
+	require.NoError(t, Load(&cfg))
+	assert.Nil(t, cfg.Endpoint.User)
+	assert.Nil(t, cfg.Logger)
+}
+
+type node struct {
+	N    int `env:"N"`
+	Next *node
+}
+
+func TestLoad_RecursiveStruct(t *testing.T) {
+	var cfg node
+	err := Load(&cfg)
+
+	assert.ErrorContains(t, err, "recursive struct envutils.node in field Next")
+}
+
+func TestLoad_UnsupportedType(t *testing.T) {
+	t.Setenv("CH", "x")
+
+	var cfg struct {
+		Ch chan int `env:"CH"`
+	}
+	err := Load(&cfg)
+
+	assert.ErrorContains(t, err, "unsupported type chan int")
+}
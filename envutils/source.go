@@ -0,0 +1,254 @@
+package envutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Lookuper looks up configuration values by key, like os.LookupEnv.
+type Lookuper interface {
+	Lookup(key string) (string, bool)
+}
+
+// LookuperFunc adapts a function to the Lookuper interface.
+type LookuperFunc func(key string) (string, bool)
+
+// Lookup calls f(key).
+func (f LookuperFunc) Lookup(key string) (string, bool) {
+	return f(key)
+}
+
+// Source is a named Lookuper. Its name is reported as the origin of its values.
+type Source interface {
+	Lookuper
+	Name() string
+}
+
+type namedSource struct {
+	name string
+	Lookuper
+}
+
+func (s namedSource) Name() string {
+	return s.name
+}
+
+// NewSource names a Lookuper.
+func NewSource(name string, l Lookuper) Source {
+	return namedSource{name: name, Lookuper: l}
+}
+
+// EnvSource returns a Source, named "env", reading the process environment.
+func EnvSource() Source {
+	return NewSource("env", LookuperFunc(os.LookupEnv))
+}
+
+// MapSource returns an in-memory Source, e.g. for defaults set in code or for tests.
+// The map is copied.
+func MapSource(name string, values map[string]string) Source {
+	m := make(map[string]string, len(values))
+	for k, v := range values {
+		m[k] = v
+	}
+	return NewSource(name, LookuperFunc(func(key string) (string, bool) {
+		v, ok := m[key]
+		return v, ok
+	}))
+}
+
+// FlagSource returns a Source, named "flags", reading the flags of fs that were set on the command line.
+// A key is looked up as the flag with the lower-cased key and underscores replaced by dashes:
+// DB_HOST is read from -db-host. Flags left to their default value are not reported, so that
+// sources with lower precedence apply.
+func FlagSource(fs *flag.FlagSet) Source {
+	return NewSource("flags", LookuperFunc(func(key string) (string, bool) {
+		name := FlagName(key)
+		var value string
+		var set bool
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name == name {
+				value, set = f.Value.String(), true
+			}
+		})
+		return value, set
+	}))
+}
+
+// FlagName returns the flag name FlagSource reads a key from.
+func FlagName(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", "-"))
+}
+
+// JSONFileSource reads a JSON object from a file and returns it as a Source named after the path.
+// See NewJSONSource for how the object is mapped to keys.
+func JSONFileSource(path string) (Source, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("envutils: reading %s: %w", path, err)
+	}
+	return NewJSONSource(path, data)
+}
+
+// NewJSONSource parses a JSON object into a Source. Nested objects are flattened, and keys are upper-cased
+// and joined with underscores: {"db": {"host": "x"}} provides DB_HOST. Arrays of scalars are joined with
+// DefaultSeparator, other values use their JSON representation, and null values are skipped.
+func NewJSONSource(name string, data []byte) (Source, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("envutils: parsing %s: %w", name, err)
+	}
+
+	values := make(map[string]string)
+	if err := flattenJSON(values, "", obj); err != nil {
+		return nil, fmt.Errorf("envutils: parsing %s: %w", name, err)
+	}
+	return MapSource(name, values), nil
+}
+
+func flattenJSON(values map[string]string, prefix string, obj map[string]any) error {
+	for k, v := range obj {
+		key := prefix + strings.ToUpper(k)
+		switch v := v.(type) {
+		case nil:
+		case map[string]any:
+			if err := flattenJSON(values, key+"_", v); err != nil {
+				return err
+			}
+		case []any:
+			parts := make([]string, len(v))
+			for i, e := range v {
+				s, err := jsonScalar(e)
+				if err != nil {
+					return err
+				}
+				parts[i] = s
+			}
+			values[key] = strings.Join(parts, DefaultSeparator)
+		default:
+			s, err := jsonScalar(v)
+			if err != nil {
+				return err
+			}
+			values[key] = s
+		}
+	}
+	return nil
+}
+
+func jsonScalar(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return fmt.Sprint(v), nil
+	default:
+		b, err := json.Marshal(v)
+		return string(b), err
+	}
+}
+
+// Origin tells where the value of a key came from.
+type Origin struct {
+	// Key is the looked up key.
+	Key string
+	// Source is the name of the source that provided the value.
+	Source string
+	// Shadowed lists the sources with lower precedence that also define the key, highest first.
+	Shadowed []string
+}
+
+// originFunc returns a function naming the source of a key read from l: the source reported by
+// its Origin method if it has one, such as Merged, or its name if it is a Source.
+func originFunc(l Lookuper) func(key string) string {
+	switch l := l.(type) {
+	case interface {
+		Origin(key string) (Origin, bool)
+	}:
+		return func(key string) string {
+			o, _ := l.Origin(key)
+			return o.Source
+		}
+	case Source:
+		return func(string) string { return l.Name() }
+	default:
+		return func(string) string { return "custom" }
+	}
+}
+
+// Merged is a Source merging several sources by precedence.
+type Merged struct {
+	sources []Source
+}
+
+// Merge merges sources listed by increasing precedence: a source overrides the ones before it.
+//
+//	envutils.Merge(defaults, jsonFile, envutils.EnvSource(), envutils.FlagSource(flag.CommandLine))
+func Merge(sources ...Source) *Merged {
+	return &Merged{sources: sources}
+}
+
+// Name returns "merged".
+func (m *Merged) Name() string {
+	return "merged"
+}
+
+// Lookup returns the value of the key from the source with the highest precedence defining it.
+// Empty values, which Load treats as unset, do not mask the sources with lower precedence, so that
+// e.g. PORT= in the environment keeps the port of a configuration file.
+func (m *Merged) Lookup(key string) (string, bool) {
+	for i := len(m.sources) - 1; i >= 0; i-- {
+		if v, ok := lookupNonEmpty(m.sources[i], key); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Origin reports which source provides the value of the key, or false if no source defines it.
+func (m *Merged) Origin(key string) (Origin, bool) {
+	o := Origin{Key: key}
+	for i := len(m.sources) - 1; i >= 0; i-- {
+		if _, ok := lookupNonEmpty(m.sources[i], key); !ok {
+			continue
+		}
+		if o.Source == "" {
+			o.Source = m.sources[i].Name()
+		} else {
+			o.Shadowed = append(o.Shadowed, m.sources[i].Name())
+		}
+	}
+	return o, o.Source != ""
+}
+
+// lookupNonEmpty looks up the key in the source, treating blank values as unset.
+func lookupNonEmpty(s Source, key string) (string, bool) {
+	v, ok := s.Lookup(key)
+	if !ok || strings.TrimSpace(v) == "" {
+		return "", false
+	}
+	return v, true
+}
+
+// Origins reports the origin of every given key defined by a source, sorted by key.
+func (m *Merged) Origins(keys ...string) []Origin {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+
+	var origins []Origin
+	for _, k := range sorted {
+		if o, ok := m.Origin(k); ok {
+			origins = append(origins, o)
+		}
+	}
+	return origins
+}
@@ -0,0 +1,179 @@
+package envutils
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapSource(t *testing.T) {
+	values := map[string]string{"PORT": "8080"}
+	s := MapSource("defaults", values)
+	values["PORT"] = "changed"
+
+	v, ok := s.Lookup("PORT")
+	assert.True(t, ok)
+	assert.Equal(t, "8080", v)
+	assert.Equal(t, "defaults", s.Name())
+
+	_, ok = s.Lookup("MISSING")
+	assert.False(t, ok)
+}
+
+func TestEnvSource(t *testing.T) {
+	t.Setenv("SOURCE_PORT", "8080")
+	s := EnvSource()
+
+	v, ok := s.Lookup("SOURCE_PORT")
+	assert.True(t, ok)
+	assert.Equal(t, "8080", v)
+	assert.Equal(t, "env", s.Name())
+}
+
+func TestFlagSource(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("db-host", "localhost", "")
+	fs.Int("port", 8080, "")
+	fs.Bool("debug", false, "")
+	require.NoError(t, fs.Parse([]string{"-db-host", "db.internal", "-debug"}))
+
+	s := FlagSource(fs)
+
+	v, ok := s.Lookup("DB_HOST")
+	assert.True(t, ok)
+	assert.Equal(t, "db.internal", v)
+
+	v, ok = s.Lookup("DEBUG")
+	assert.True(t, ok)
+	assert.Equal(t, "true", v)
+
+	_, ok = s.Lookup("PORT")
+	assert.False(t, ok, "flags left to their default are not reported")
+}
+
+func TestNewJSONSource(t *testing.T) {
+	s, err := NewJSONSource("config.json", []byte(`{
+		"name": "orders",
+		"port": 8080,
+		"ratio": 0.25,
+		"debug": true,
+		"hosts": ["a", "b"],
+		"db": {"host": "db.internal", "pool": {"size": 10}},
+		"unset": null
+	}`))
+	require.NoError(t, err)
+
+	expected := map[string]string{
+		"NAME":         "orders",
+		"PORT":         "8080",
+		"RATIO":        "0.25",
+		"DEBUG":        "true",
+		"HOSTS":        "a,b",
+		"DB_HOST":      "db.internal",
+		"DB_POOL_SIZE": "10",
+	}
+	for k, want := range expected {
+		v, ok := s.Lookup(k)
+		assert.True(t, ok, k)
+		assert.Equal(t, want, v, k)
+	}
+	_, ok := s.Lookup("UNSET")
+	assert.False(t, ok)
+	assert.Equal(t, "config.json", s.Name())
+}
+
+func TestJSONFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"port": 9090}`), 0o600))
+
+	s, err := JSONFileSource(path)
+	require.NoError(t, err)
+	v, _ := s.Lookup("PORT")
+	assert.Equal(t, "9090", v)
+	assert.Equal(t, path, s.Name())
+
+	_, err = JSONFileSource(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte(`[1, 2]`), 0o600))
+	_, err = JSONFileSource(path)
+	assert.Error(t, err)
+}
+
+func TestMerged(t *testing.T) {
+	m := Merge(
+		MapSource("defaults", map[string]string{"PORT": "8080", "HOST": "localhost", "DEBUG": "false"}),
+		MapSource("file", map[string]string{"PORT": "9090", "HOST": "file.internal"}),
+		MapSource("env", map[string]string{"PORT": "7070"}),
+	)
+
+	v, ok := m.Lookup("PORT")
+	assert.True(t, ok)
+	assert.Equal(t, "7070", v)
+
+	v, _ = m.Lookup("HOST")
+	assert.Equal(t, "file.internal", v)
+
+	_, ok = m.Lookup("MISSING")
+	assert.False(t, ok)
+
+	o, ok := m.Origin("PORT")
+	assert.True(t, ok)
+	assert.Equal(t, Origin{Key: "PORT", Source: "env", Shadowed: []string{"file", "defaults"}}, o)
+
+	_, ok = m.Origin("MISSING")
+	assert.False(t, ok)
+
+	assert.Equal(t, []Origin{
+		{Key: "DEBUG", Source: "defaults"},
+		{Key: "HOST", Source: "file", Shadowed: []string{"defaults"}},
+		{Key: "PORT", Source: "env", Shadowed: []string{"file", "defaults"}},
+	}, m.Origins("PORT", "HOST", "DEBUG", "MISSING"))
+}
+
+func TestMerged_EmptyValueDoesNotMask(t *testing.T) {
+	file, err := NewJSONSource("cfg.json", []byte(`{"port": 9000}`))
+	require.NoError(t, err)
+	m := Merge(file, MapSource("env", map[string]string{"PORT": ""}))
+
+	v, ok := m.Lookup("PORT")
+	assert.True(t, ok)
+	assert.Equal(t, "9000", v)
+
+	o, ok := m.Origin("PORT")
+	assert.True(t, ok)
+	assert.Equal(t, Origin{Key: "PORT", Source: "cfg.json"}, o)
+
+	var cfg struct {
+		Port int `env:"PORT" default:"8080"`
+	}
+	require.NoError(t, Load(&cfg, WithLookuper(m)))
+	assert.Equal(t, 9000, cfg.Port)
+}
+
+func TestLoad_WithLookuper(t *testing.T) {
+	t.Setenv("NAME", "from-env")
+	src := MapSource("test", map[string]string{"NAME": "orders", "DB_PORT": "6432"})
+
+	var cfg testConfig
+	require.NoError(t, Load(&cfg, WithLookuper(src)))
+
+	assert.Equal(t, "orders", cfg.Name)
+	assert.Equal(t, 6432, cfg.DB.Port)
+	assert.Equal(t, 8080, cfg.Port)
+}
+
+func TestKeys(t *testing.T) {
+	var cfg dbConfig
+	keys, err := Keys(&cfg, WithPrefix("APP_"))
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"APP_HOST", "APP_PORT"}, keys)
+
+	_, err = Keys(cfg)
+	assert.Error(t, err)
+}
@@ -0,0 +1,352 @@
+package envutils
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotAllowed is wrapped by the VarError of an enum variable set to a value outside the allowed set.
+var ErrNotAllowed = errors.New("value is not allowed")
+
+// lookupTrimmed returns the trimmed value of the variable, or of the file named by its _FILE variant,
+// treating empty values as unset. fromFile reports whether the value was read from a file.
+func lookupTrimmed(key string) (value string, ok, fromFile bool, err error) {
+	value, ok, fromFile, err = lookupEnv(key)
+	return value, ok && value != "", fromFile, err
+}
+
+// getStrict parses the variable with parse, returning defaultVal when it is unset
+// and a *VarError when it cannot be read or parsed. Values read from files are treated as secret.
+func getStrict[T any](key string, defaultVal T, expected string, secret bool, parse func(string) (T, error)) (T, error) {
+	raw, ok, fromFile, err := lookupTrimmed(key)
+	secret = secret || fromFile
+	if err != nil {
+		return defaultVal, newVarError(key, "", expected, secret, err)
+	}
+	if !ok {
+		return defaultVal, nil
+	}
+
+	v, err := parse(raw)
+	if err != nil {
+		return defaultVal, newVarError(key, raw, expected, secret, err)
+	}
+	return v, nil
+}
+
+func parseString(s string) (string, error) {
+	return s, nil
+}
+
+func parseInt(s string) (int, error) {
+	return strconv.Atoi(s)
+}
+
+func parseInt32(s string) (int32, error) {
+	i, err := strconv.ParseInt(s, 10, 32)
+	return int32(i), err
+}
+
+// parseAs parses s into a T the way Load does, with the default separators for slices and maps.
+func parseAs[T any](s string) (T, error) {
+	var v T
+	err := decode(reflect.ValueOf(&v).Elem(), s, DefaultSeparator, DefaultKVSeparator)
+	return v, err
+}
+
+// getAs is getStrict with parseAs, reporting the type of T as expected.
+func getAs[T any](key string, defaultVal T) (T, error) {
+	return getStrict(key, defaultVal, typeName(reflect.TypeFor[T]()), false, parseAs[T])
+}
+
+// parseEnum returns a parser accepting only the allowed values.
+func parseEnum[T ~string](allowed []T) func(string) (T, error) {
+	return func(s string) (T, error) {
+		if !slices.Contains(allowed, T(s)) {
+			names := make([]string, len(allowed))
+			for i, a := range allowed {
+				names[i] = string(a)
+			}
+			return "", fmt.Errorf("%w, expected one of: %s", ErrNotAllowed, strings.Join(names, ", "))
+		}
+		return T(s), nil
+	}
+}
+
+// GetEnvAsBoolStrict retrieves the value of the environment variable named by the key and parses it as a boolean.
+// If the variable is not present, it returns the default value. If it cannot be parsed, it returns a *VarError.
+func GetEnvAsBoolStrict(key string, defaultVal bool) (bool, error) {
+	return getStrict(key, defaultVal, "bool", false, strconv.ParseBool)
+}
+
+// GetEnvAsIntStrict retrieves the value of the environment variable named by the key and parses it as an integer.
+// If the variable is not present, it returns the default value. If it cannot be parsed, it returns a *VarError.
+func GetEnvAsIntStrict(key string, defaultVal int) (int, error) {
+	return getStrict(key, defaultVal, "int", false, parseInt)
+}
+
+// GetEnvAsInt32Strict retrieves the value of the environment variable named by the key and parses it as an int32.
+// If the variable is not present, it returns the default value. If it cannot be parsed, it returns a *VarError.
+func GetEnvAsInt32Strict(key string, defaultVal int32) (int32, error) {
+	return getStrict(key, defaultVal, "int32", false, parseInt32)
+}
+
+// GetEnvAsInt64Strict retrieves the value of the environment variable named by the key and parses it as an int64.
+// If the variable is not present, it returns the default value. If it cannot be parsed, it returns a *VarError.
+func GetEnvAsInt64Strict(key string, defaultVal int64) (int64, error) {
+	return getAs(key, defaultVal)
+}
+
+// GetEnvAsUint64Strict retrieves the value of the environment variable named by the key and parses it as a uint64.
+// If the variable is not present, it returns the default value. If it cannot be parsed, it returns a *VarError.
+func GetEnvAsUint64Strict(key string, defaultVal uint64) (uint64, error) {
+	return getAs(key, defaultVal)
+}
+
+// GetEnvAsFloat64Strict retrieves the value of the environment variable named by the key and parses it as a float64.
+// If the variable is not present, it returns the default value. If it cannot be parsed, it returns a *VarError.
+func GetEnvAsFloat64Strict(key string, defaultVal float64) (float64, error) {
+	return getAs(key, defaultVal)
+}
+
+// GetEnvAsDurationStrict retrieves the value of the environment variable named by the key and parses it as a time.Duration.
+// If the variable is not present, it returns the default value. If it cannot be parsed, it returns a *VarError.
+func GetEnvAsDurationStrict(key string, defaultVal time.Duration) (time.Duration, error) {
+	return getAs(key, defaultVal)
+}
+
+// GetEnvAsSliceStrict retrieves the value of the environment variable named by the key as a comma-separated list.
+// Elements are trimmed and empty ones dropped. If the variable is not present, it returns the default value.
+func GetEnvAsSliceStrict(key string, defaultVal []string) ([]string, error) {
+	return getAs(key, defaultVal)
+}
+
+// GetEnvAsMapStrict retrieves the value of the environment variable named by the key as comma-separated key=value pairs.
+// If the variable is not present, it returns the default value. If a pair has no =, it returns a *VarError.
+func GetEnvAsMapStrict(key string, defaultVal map[string]string) (map[string]string, error) {
+	return getAs(key, defaultVal)
+}
+
+// GetEnvAsURLStrict retrieves the value of the environment variable named by the key and parses it as an absolute URL.
+// If the variable is not present, it returns the default value. If it cannot be parsed, it returns a *VarError.
+func GetEnvAsURLStrict(key string, defaultVal *url.URL) (*url.URL, error) {
+	return getAs(key, defaultVal)
+}
+
+// GetEnvAsByteSizeStrict retrieves the value of the environment variable named by the key and parses it with ParseByteSize.
+// If the variable is not present, it returns the default value. If it cannot be parsed, it returns a *VarError.
+func GetEnvAsByteSizeStrict(key string, defaultVal ByteSize) (ByteSize, error) {
+	return getAs(key, defaultVal)
+}
+
+// GetEnvAsLocationStrict retrieves the value of the environment variable named by the key and loads it as a time zone,
+// such as "Europe/Paris" or "UTC". If the variable is not present, it returns the default value. If the location
+// cannot be loaded, it returns a *VarError.
+func GetEnvAsLocationStrict(key string, defaultVal *time.Location) (*time.Location, error) {
+	return getAs(key, defaultVal)
+}
+
+// GetEnvAsEnumStrict retrieves the value of the environment variable named by the key, which must be one of the
+// allowed values. If the variable is not present, it returns the default value. If it is not allowed, it returns
+// a *VarError wrapping ErrNotAllowed.
+func GetEnvAsEnumStrict[T ~string](key string, defaultVal T, allowed ...T) (T, error) {
+	return getStrict(key, defaultVal, "enum", false, parseEnum(allowed))
+}
+
+// Collector reads environment variables and gathers every missing or invalid one,
+// so that all of them can be reported at once at startup instead of failing on the first.
+type Collector struct {
+	secrets map[string]bool
+	errs    Errors
+}
+
+// NewCollector creates an empty Collector.
+func NewCollector() *Collector {
+	return &Collector{secrets: make(map[string]bool)}
+}
+
+// MarkSecret marks variables whose values must be redacted from errors.
+func (c *Collector) MarkSecret(keys ...string) *Collector {
+	for _, k := range keys {
+		c.secrets[k] = true
+	}
+	return c
+}
+
+// String returns the trimmed value of the variable, or the default value if it is not present.
+func (c *Collector) String(key string, defaultVal string) string {
+	return collect(c, key, defaultVal, "string", parseString)
+}
+
+// RequiredString returns the trimmed value of the variable, recording an error if it is not present.
+func (c *Collector) RequiredString(key string) string {
+	return collectRequired(c, key, "string", parseString)
+}
+
+// Bool parses the variable as a boolean, or returns the default value if it is not present.
+func (c *Collector) Bool(key string, defaultVal bool) bool {
+	return collect(c, key, defaultVal, "bool", strconv.ParseBool)
+}
+
+// Int parses the variable as an integer, or returns the default value if it is not present.
+func (c *Collector) Int(key string, defaultVal int) int {
+	return collect(c, key, defaultVal, "int", parseInt)
+}
+
+// Int32 parses the variable as an int32, or returns the default value if it is not present.
+func (c *Collector) Int32(key string, defaultVal int32) int32 {
+	return collect(c, key, defaultVal, "int32", parseInt32)
+}
+
+// Duration parses the variable as a time.Duration, or returns the default value if it is not present.
+func (c *Collector) Duration(key string, defaultVal time.Duration) time.Duration {
+	return collect(c, key, defaultVal, "duration", time.ParseDuration)
+}
+
+// Int64 parses the variable as an int64, or returns the default value if it is not present.
+func (c *Collector) Int64(key string, defaultVal int64) int64 {
+	return collectAs(c, key, defaultVal)
+}
+
+// Uint64 parses the variable as a uint64, or returns the default value if it is not present.
+func (c *Collector) Uint64(key string, defaultVal uint64) uint64 {
+	return collectAs(c, key, defaultVal)
+}
+
+// Float64 parses the variable as a float64, or returns the default value if it is not present.
+func (c *Collector) Float64(key string, defaultVal float64) float64 {
+	return collectAs(c, key, defaultVal)
+}
+
+// Slice parses the variable as a comma-separated list, or returns the default value if it is not present.
+func (c *Collector) Slice(key string, defaultVal []string) []string {
+	return collectAs(c, key, defaultVal)
+}
+
+// Map parses the variable as comma-separated key=value pairs, or returns the default value if it is not present.
+func (c *Collector) Map(key string, defaultVal map[string]string) map[string]string {
+	return collectAs(c, key, defaultVal)
+}
+
+// URL parses the variable as an absolute URL, or returns the default value if it is not present.
+func (c *Collector) URL(key string, defaultVal *url.URL) *url.URL {
+	return collectAs(c, key, defaultVal)
+}
+
+// ByteSize parses the variable with ParseByteSize, or returns the default value if it is not present.
+func (c *Collector) ByteSize(key string, defaultVal ByteSize) ByteSize {
+	return collectAs(c, key, defaultVal)
+}
+
+// Location loads the variable as a time zone, or returns the default value if it is not present.
+func (c *Collector) Location(key string, defaultVal *time.Location) *time.Location {
+	return collectAs(c, key, defaultVal)
+}
+
+// Enum returns the variable, recording an error if it is not one of the allowed values,
+// or returns the default value if it is not present.
+func (c *Collector) Enum(key string, defaultVal string, allowed ...string) string {
+	return collect(c, key, defaultVal, "enum", parseEnum(allowed))
+}
+
+// RequiredBool parses the variable as a boolean, recording an error if it is not present.
+func (c *Collector) RequiredBool(key string) bool {
+	return collectRequired(c, key, "bool", strconv.ParseBool)
+}
+
+// RequiredInt parses the variable as an integer, recording an error if it is not present.
+func (c *Collector) RequiredInt(key string) int {
+	return collectRequired(c, key, "int", parseInt)
+}
+
+// RequiredInt32 parses the variable as an int32, recording an error if it is not present.
+func (c *Collector) RequiredInt32(key string) int32 {
+	return collectRequired(c, key, "int32", parseInt32)
+}
+
+// RequiredInt64 parses the variable as an int64, recording an error if it is not present.
+func (c *Collector) RequiredInt64(key string) int64 {
+	return collectRequiredAs[int64](c, key)
+}
+
+// RequiredUint64 parses the variable as a uint64, recording an error if it is not present.
+func (c *Collector) RequiredUint64(key string) uint64 {
+	return collectRequiredAs[uint64](c, key)
+}
+
+// RequiredFloat64 parses the variable as a float64, recording an error if it is not present.
+func (c *Collector) RequiredFloat64(key string) float64 {
+	return collectRequiredAs[float64](c, key)
+}
+
+// RequiredDuration parses the variable as a time.Duration, recording an error if it is not present.
+func (c *Collector) RequiredDuration(key string) time.Duration {
+	return collectRequired(c, key, "duration", time.ParseDuration)
+}
+
+// RequiredSlice parses the variable as a comma-separated list, recording an error if it is not present.
+func (c *Collector) RequiredSlice(key string) []string {
+	return collectRequiredAs[[]string](c, key)
+}
+
+// RequiredMap parses the variable as comma-separated key=value pairs, recording an error if it is not present.
+func (c *Collector) RequiredMap(key string) map[string]string {
+	return collectRequiredAs[map[string]string](c, key)
+}
+
+// RequiredURL parses the variable as an absolute URL, recording an error if it is not present.
+func (c *Collector) RequiredURL(key string) *url.URL {
+	return collectRequiredAs[*url.URL](c, key)
+}
+
+// RequiredByteSize parses the variable with ParseByteSize, recording an error if it is not present.
+func (c *Collector) RequiredByteSize(key string) ByteSize {
+	return collectRequiredAs[ByteSize](c, key)
+}
+
+// RequiredLocation loads the variable as a time zone, recording an error if it is not present.
+func (c *Collector) RequiredLocation(key string) *time.Location {
+	return collectRequiredAs[*time.Location](c, key)
+}
+
+// RequiredEnum returns the variable, recording an error if it is not present or not one of the allowed values.
+func (c *Collector) RequiredEnum(key string, allowed ...string) string {
+	return collectRequired(c, key, "enum", parseEnum(allowed))
+}
+
+// Err returns an Errors value listing every missing or invalid variable, or nil if there were none.
+func (c *Collector) Err() error {
+	return c.errs.errOrNil()
+}
+
+func collectAs[T any](c *Collector, key string, defaultVal T) T {
+	return collect(c, key, defaultVal, typeName(reflect.TypeFor[T]()), parseAs[T])
+}
+
+// collectRequired is collect for a variable without default value, recording an ErrMissing error when it is not present.
+func collectRequired[T any](c *Collector, key, expected string, parse func(string) (T, error)) T {
+	var zero T
+	if _, ok, _, err := lookupTrimmed(key); err == nil && !ok {
+		c.errs = append(c.errs, missingVarError(key, expected, c.secrets[key]))
+		return zero
+	}
+	return collect(c, key, zero, expected, parse)
+}
+
+func collectRequiredAs[T any](c *Collector, key string) T {
+	return collectRequired(c, key, typeName(reflect.TypeFor[T]()), parseAs[T])
+}
+
+func collect[T any](c *Collector, key string, defaultVal T, expected string, parse func(string) (T, error)) T {
+	v, err := getStrict(key, defaultVal, expected, c.secrets[key], parse)
+	if err != nil {
+		c.errs = append(c.errs, err.(*VarError))
+	}
+	return v
+}
@@ -0,0 +1,117 @@
+package envutils
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetEnvAsIntStrict tests the GetEnvAsIntStrict function.
+func TestGetEnvAsIntStrict(t *testing.T) {
+	t.Setenv("INT_KEY", "123")
+	t.Setenv("INVALID_INT", "80a")
+
+	tests := []struct {
+		key        string
+		defaultVal int
+		expected   int
+		err        bool
+	}{
+		{"INT_KEY", 0, 123, false},
+		{"MISSING_INT", 456, 456, false},
+		{"INVALID_INT", 789, 789, true},
+	}
+
+	for _, test := range tests {
+		result, err := GetEnvAsIntStrict(test.key, test.defaultVal)
+		if (err != nil) != test.err {
+			t.Errorf("GetEnvAsIntStrict(%q, %d) error = %v; expected error = %v", test.key, test.defaultVal, err, test.err)
+		}
+		if result != test.expected {
+			t.Errorf("GetEnvAsIntStrict(%q, %d) = %d; expected %d", test.key, test.defaultVal, result, test.expected)
+		}
+	}
+}
+
+// TestGetEnvAsBoolStrict tests the GetEnvAsBoolStrict function.
+func TestGetEnvAsBoolStrict(t *testing.T) {
+	t.Setenv("BOOL_KEY", "true")
+	t.Setenv("INVALID_BOOL", "yes please")
+
+	b, err := GetEnvAsBoolStrict("BOOL_KEY", false)
+	assert.NoError(t, err)
+	assert.True(t, b)
+
+	b, err = GetEnvAsBoolStrict("INVALID_BOOL", true)
+	assert.True(t, b)
+
+	var ve *VarError
+	require.ErrorAs(t, err, &ve)
+	assert.Equal(t, "INVALID_BOOL", ve.Key)
+	assert.Equal(t, "yes please", ve.Value)
+	assert.Equal(t, "bool", ve.Expected)
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+}
+
+// TestGetEnvAsInt32Strict tests the GetEnvAsInt32Strict function.
+func TestGetEnvAsInt32Strict(t *testing.T) {
+	t.Setenv("INT32_KEY", "5000000000")
+
+	i, err := GetEnvAsInt32Strict("INT32_KEY", 7)
+
+	assert.Equal(t, int32(7), i)
+	assert.ErrorIs(t, err, strconv.ErrRange)
+	assert.EqualError(t, err, `INT32_KEY: invalid value "5000000000" (expected int32): value out of range`)
+}
+
+// TestCollector tests that the Collector reports every invalid variable at once.
+func TestCollector(t *testing.T) {
+	t.Setenv("PORT", "80a")
+	t.Setenv("DEBUG", "true")
+	t.Setenv("WORKERS", "4")
+	t.Setenv("TIMEOUT", "soon")
+	t.Setenv("API_KEY", "abc")
+	t.Setenv("DB_POOL", "lots")
+
+	c := NewCollector().MarkSecret("DB_POOL")
+	port := c.Int("PORT", 8080)
+	debug := c.Bool("DEBUG", false)
+	workers := c.Int32("WORKERS", 1)
+	timeout := c.Duration("TIMEOUT", time.Second)
+	host := c.String("HOST", "localhost")
+	apiKey := c.RequiredString("API_KEY")
+	dsn := c.RequiredString("DSN")
+	pool := c.Int("DB_POOL", 10)
+
+	assert.Equal(t, 8080, port)
+	assert.True(t, debug)
+	assert.Equal(t, int32(4), workers)
+	assert.Equal(t, time.Second, timeout)
+	assert.Equal(t, "localhost", host)
+	assert.Equal(t, "abc", apiKey)
+	assert.Empty(t, dsn)
+	assert.Equal(t, 10, pool)
+
+	err := c.Err()
+	var errs Errors
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 4)
+	assert.Equal(t, []string{"PORT", "TIMEOUT", "DSN", "DB_POOL"}, []string{errs[0].Key, errs[1].Key, errs[2].Key, errs[3].Key})
+	assert.ErrorIs(t, err, ErrMissing)
+	assert.Contains(t, err.Error(), "envutils: 4 invalid environment variable(s)")
+	assert.Contains(t, err.Error(), `PORT: invalid value "80a" (expected int)`)
+	assert.Contains(t, err.Error(), "DB_POOL: invalid value [REDACTED] (expected int)")
+	assert.NotContains(t, err.Error(), "lots")
+}
+
+// TestCollector_NoErrors tests that Err returns nil when every variable is valid.
+func TestCollector_NoErrors(t *testing.T) {
+	c := NewCollector()
+	c.Int("MISSING_PORT", 8080)
+
+	assert.NoError(t, c.Err())
+}
@@ -0,0 +1,213 @@
+package envutils
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetEnvAsNumbers(t *testing.T) {
+	t.Setenv("INT64_KEY", "-9000000000")
+	t.Setenv("UINT64_KEY", "18000000000000000000")
+	t.Setenv("FLOAT64_KEY", "0.25")
+	t.Setenv("INVALID_NUMBER", "lots")
+
+	assert.Equal(t, int64(-9000000000), GetEnvAsInt64("INT64_KEY", 1))
+	assert.Equal(t, int64(1), GetEnvAsInt64("MISSING_KEY", 1))
+	assert.Equal(t, int64(1), GetEnvAsInt64("INVALID_NUMBER", 1))
+	assert.Equal(t, uint64(18000000000000000000), GetEnvAsUint64("UINT64_KEY", 1))
+	assert.Equal(t, uint64(1), GetEnvAsUint64("INT64_KEY", 1))
+	assert.Equal(t, 0.25, GetEnvAsFloat64("FLOAT64_KEY", 1))
+	assert.Equal(t, 1.5, GetEnvAsFloat64("MISSING_KEY", 1.5))
+
+	_, err := GetEnvAsUint64Strict("INT64_KEY", 1)
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+	assert.EqualError(t, err, `INT64_KEY: invalid value "-9000000000" (expected uint64): invalid syntax`)
+
+	_, err = GetEnvAsFloat64Strict("INVALID_NUMBER", 1)
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+}
+
+func TestGetEnvAsDurationStrict(t *testing.T) {
+	t.Setenv("DURATION_KEY", "90s")
+	t.Setenv("INVALID_DURATION", "soon")
+
+	d, err := GetEnvAsDurationStrict("DURATION_KEY", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, d)
+
+	d, err = GetEnvAsDurationStrict("MISSING_KEY", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, d)
+
+	d, err = GetEnvAsDurationStrict("INVALID_DURATION", time.Second)
+	assert.Error(t, err)
+	assert.Equal(t, time.Second, d)
+}
+
+func TestGetEnvAsSliceAndMap(t *testing.T) {
+	t.Setenv("SLICE_KEY", " a, b ,,c ")
+	t.Setenv("MAP_KEY", "team=core, tier = 1")
+	t.Setenv("INVALID_MAP", "team")
+
+	assert.Equal(t, []string{"a", "b", "c"}, GetEnvAsSlice("SLICE_KEY", nil))
+	assert.Equal(t, []string{"x"}, GetEnvAsSlice("MISSING_KEY", []string{"x"}))
+	assert.Equal(t, map[string]string{"team": "core", "tier": "1"}, GetEnvAsMap("MAP_KEY", nil))
+	assert.Equal(t, map[string]string{"k": "v"}, GetEnvAsMap("INVALID_MAP", map[string]string{"k": "v"}))
+
+	_, err := GetEnvAsMapStrict("INVALID_MAP", nil)
+	assert.ErrorContains(t, err, `INVALID_MAP: invalid value "team" (expected map[string]string)`)
+}
+
+func TestGetEnvAsURL(t *testing.T) {
+	t.Setenv("URL_KEY", "https://api.example.com:8443/v1?x=1")
+	t.Setenv("RELATIVE_URL", "api.example.com/v1")
+	t.Setenv("INVALID_URL", "http://[::1")
+	def, _ := url.Parse("http://localhost")
+
+	u := GetEnvAsURL("URL_KEY", def)
+	require.NotNil(t, u)
+	assert.Equal(t, "api.example.com:8443", u.Host)
+	assert.Equal(t, "/v1", u.Path)
+	assert.Same(t, def, GetEnvAsURL("MISSING_KEY", def))
+	assert.Same(t, def, GetEnvAsURL("RELATIVE_URL", def))
+
+	_, err := GetEnvAsURLStrict("RELATIVE_URL", nil)
+	assert.EqualError(t, err, `RELATIVE_URL: invalid value "api.example.com/v1" (expected url.URL): URL is missing a scheme`)
+
+	_, err = GetEnvAsURLStrict("INVALID_URL", nil)
+	assert.ErrorContains(t, err, "missing ']' in host")
+}
+
+func TestGetEnvAsByteSize(t *testing.T) {
+	t.Setenv("SIZE_KEY", "512MiB")
+	t.Setenv("INVALID_SIZE", "big")
+
+	assert.Equal(t, 512*MiB, GetEnvAsByteSize("SIZE_KEY", KiB))
+	assert.Equal(t, KiB, GetEnvAsByteSize("MISSING_KEY", KiB))
+	assert.Equal(t, KiB, GetEnvAsByteSize("INVALID_SIZE", KiB))
+
+	_, err := GetEnvAsByteSizeStrict("INVALID_SIZE", KiB)
+	assert.ErrorContains(t, err, "expected envutils.ByteSize")
+}
+
+func TestGetEnvAsLocation(t *testing.T) {
+	t.Setenv("TZ_KEY", "UTC")
+	t.Setenv("INVALID_TZ", "Mars/Olympus_Mons")
+
+	assert.Same(t, time.UTC, GetEnvAsLocation("TZ_KEY", time.Local))
+	assert.Same(t, time.Local, GetEnvAsLocation("MISSING_KEY", time.Local))
+	assert.Same(t, time.Local, GetEnvAsLocation("INVALID_TZ", time.Local))
+
+	_, err := GetEnvAsLocationStrict("INVALID_TZ", time.Local)
+	assert.ErrorContains(t, err, `INVALID_TZ: invalid value "Mars/Olympus_Mons" (expected time.Location)`)
+}
+
+type logFormat string
+
+func TestGetEnvAsEnum(t *testing.T) {
+	t.Setenv("ENUM_KEY", "json")
+	t.Setenv("INVALID_ENUM", "xml")
+
+	assert.Equal(t, logFormat("json"), GetEnvAsEnum("ENUM_KEY", logFormat("text"), "text", "json"))
+	assert.Equal(t, logFormat("text"), GetEnvAsEnum("MISSING_KEY", logFormat("text"), "text", "json"))
+	assert.Equal(t, logFormat("text"), GetEnvAsEnum("INVALID_ENUM", logFormat("text"), "text", "json"))
+
+	v, err := GetEnvAsEnumStrict("INVALID_ENUM", "text", "text", "json")
+	assert.Equal(t, "text", v)
+	assert.ErrorIs(t, err, ErrNotAllowed)
+	assert.EqualError(t, err, `INVALID_ENUM: invalid value "xml" (expected enum): value is not allowed, expected one of: text, json`)
+}
+
+func TestCollector_Typed(t *testing.T) {
+	t.Setenv("C_INT64", "5")
+	t.Setenv("C_UINT64", "-5")
+	t.Setenv("C_FLOAT64", "2.5")
+	t.Setenv("C_SLICE", "a,b")
+	t.Setenv("C_MAP", "a=1")
+	t.Setenv("C_URL", "nope")
+	t.Setenv("C_SIZE", "1KiB")
+	t.Setenv("C_TZ", "UTC")
+	t.Setenv("C_ENUM", "xml")
+
+	c := NewCollector()
+	assert.Equal(t, int64(5), c.Int64("C_INT64", 0))
+	assert.Equal(t, uint64(7), c.Uint64("C_UINT64", 7))
+	assert.Equal(t, 2.5, c.Float64("C_FLOAT64", 0))
+	assert.Equal(t, []string{"a", "b"}, c.Slice("C_SLICE", nil))
+	assert.Equal(t, map[string]string{"a": "1"}, c.Map("C_MAP", nil))
+	assert.Nil(t, c.URL("C_URL", nil))
+	assert.Equal(t, KiB, c.ByteSize("C_SIZE", 0))
+	assert.Same(t, time.UTC, c.Location("C_TZ", nil))
+	assert.Equal(t, "text", c.Enum("C_ENUM", "text", "text", "json"))
+
+	var errs Errors
+	require.ErrorAs(t, c.Err(), &errs)
+	require.Len(t, errs, 3)
+	assert.Equal(t, "C_UINT64", errs[0].Key)
+	assert.Equal(t, "C_URL", errs[1].Key)
+	assert.Equal(t, "C_ENUM", errs[2].Key)
+}
+
+func TestCollector_Required(t *testing.T) {
+	t.Setenv("R_PORT", "8080")
+	t.Setenv("R_DEBUG", "maybe")
+	t.Setenv("R_TIMEOUT", " ")
+
+	c := NewCollector()
+	assert.Equal(t, 8080, c.RequiredInt("R_PORT"))
+	assert.False(t, c.RequiredBool("R_DEBUG"))
+	assert.Zero(t, c.RequiredDuration("R_TIMEOUT"))
+	assert.Zero(t, c.RequiredInt32("R_WORKERS"))
+	assert.Zero(t, c.RequiredInt64("R_INT64"))
+	assert.Zero(t, c.RequiredUint64("R_UINT64"))
+	assert.Zero(t, c.RequiredFloat64("R_RATIO"))
+	assert.Nil(t, c.RequiredSlice("R_HOSTS"))
+	assert.Nil(t, c.RequiredMap("R_LABELS"))
+	assert.Nil(t, c.RequiredURL("R_ENDPOINT"))
+	assert.Zero(t, c.RequiredByteSize("R_SIZE"))
+	assert.Nil(t, c.RequiredLocation("R_TZ"))
+	assert.Empty(t, c.RequiredEnum("R_FORMAT", "text", "json"))
+
+	var errs Errors
+	require.ErrorAs(t, c.Err(), &errs)
+	require.Len(t, errs, 12)
+	assert.Equal(t, "R_DEBUG", errs[0].Key)
+	assert.NotErrorIs(t, errs[0], ErrMissing, "invalid values are not reported as missing")
+	for _, e := range errs[1:] {
+		assert.ErrorIs(t, e, ErrMissing, e.Key)
+	}
+	assert.EqualError(t, errs[1], "R_TIMEOUT: required variable is not set (expected duration)")
+	assert.EqualError(t, errs[2], "R_WORKERS: required variable is not set (expected int32)")
+}
+
+func TestLoad_URLAndLocation(t *testing.T) {
+	t.Setenv("ENDPOINT", "https://api.example.com")
+	t.Setenv("BACKUP", "https://backup.example.com")
+	t.Setenv("TZ_NAME", "UTC")
+
+	var cfg struct {
+		Endpoint *url.URL       `env:"ENDPOINT"`
+		Backup   url.URL        `env:"BACKUP"`
+		Location *time.Location `env:"TZ_NAME"`
+		Missing  *url.URL       `env:"MISSING_URL"`
+	}
+	require.NoError(t, Load(&cfg))
+
+	require.NotNil(t, cfg.Endpoint)
+	assert.Equal(t, "api.example.com", cfg.Endpoint.Host)
+	assert.Equal(t, "backup.example.com", cfg.Backup.Host)
+	assert.Same(t, time.UTC, cfg.Location)
+	assert.Nil(t, cfg.Missing)
+
+	desc, err := Describe(&cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com", desc[0].Value)
+	assert.Equal(t, "https://backup.example.com", desc[1].Value)
+	assert.Equal(t, "UTC", desc[2].Value)
+	assert.Equal(t, "", desc[3].Value)
+}
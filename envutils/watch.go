@@ -0,0 +1,256 @@
+package envutils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Sectoid-Systems/sectoid-go-kit/misc"
+)
+
+// DefaultPollInterval is the default interval at which a Watcher checks its files for changes.
+const DefaultPollInterval = 5 * time.Second
+
+// Validator is implemented by configuration structs that check their own invariants after loading.
+// A Watcher rejects configurations failing validation.
+type Validator interface {
+	Validate() error
+}
+
+// WatchOption configures a Watcher.
+type WatchOption func(*watchConfig)
+
+type watchConfig struct {
+	files    []string
+	interval time.Duration
+	lf       misc.LoggerFunc
+}
+
+// WithWatchFiles sets the files whose changes trigger a reload, typically the JSON and .env files read by the load function.
+func WithWatchFiles(paths ...string) WatchOption {
+	return func(c *watchConfig) {
+		c.files = append(c.files, paths...)
+	}
+}
+
+// WithPollInterval sets the interval at which files are checked for changes. Defaults to DefaultPollInterval,
+// which is also used for non-positive intervals.
+func WithPollInterval(d time.Duration) WatchOption {
+	return func(c *watchConfig) {
+		if d <= 0 {
+			d = DefaultPollInterval
+		}
+		c.interval = d
+	}
+}
+
+// WithWatchLogger sets the function used to report rejected reloads. Defaults to log.Printf.
+func WithWatchLogger(lf misc.LoggerFunc) WatchOption {
+	return func(c *watchConfig) {
+		c.lf = lf
+	}
+}
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func (s fileStamp) equal(o fileStamp) bool {
+	return s.exists == o.exists && s.size == o.size && s.modTime.Equal(o.modTime)
+}
+
+func statFile(path string) fileStamp {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{exists: true, size: fi.Size(), modTime: fi.ModTime()}
+}
+
+type subscription[T any] struct {
+	id int
+	fn func(old, new *T)
+}
+
+// Watcher holds the current configuration of type T and reloads it when its files change.
+// Each reload runs the load function into a fresh T and validates it; valid configurations are swapped
+// in atomically and passed to subscribers, while invalid ones are logged and discarded, keeping the last good one.
+type Watcher[T any] struct {
+	load     func(cfg *T) error
+	files    []string
+	interval time.Duration
+	lf       misc.LoggerFunc
+
+	current atomic.Pointer[T]
+
+	reloading sync.Mutex
+	stamps    map[string]fileStamp
+
+	mu     sync.Mutex
+	subs   []subscription[T]
+	nextID int
+}
+
+// NewWatcher loads the initial configuration with load, which defaults to Load with the process environment
+// when nil, and returns an error if it fails or does not validate. The load function must read its sources
+// anew on every call, e.g. with JSONFileSource:
+//
+//	w, err := envutils.NewWatcher(func(cfg *Config) error {
+//		file, err := envutils.JSONFileSource("config.json")
+//		if err != nil {
+//			return err
+//		}
+//		return envutils.Load(cfg, envutils.WithLookuper(envutils.Merge(file, envutils.EnvSource())))
+//	}, envutils.WithWatchFiles("config.json"))
+func NewWatcher[T any](load func(cfg *T) error, opts ...WatchOption) (*Watcher[T], error) {
+	c := watchConfig{interval: DefaultPollInterval, lf: log.Printf}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if load == nil {
+		load = func(cfg *T) error { return Load(cfg) }
+	}
+
+	w := &Watcher[T]{
+		load:     load,
+		files:    c.files,
+		interval: c.interval,
+		lf:       c.lf,
+		stamps:   make(map[string]fileStamp, len(c.files)),
+	}
+	for _, f := range w.files {
+		w.stamps[f] = statFile(f)
+	}
+
+	cfg, err := w.loadFresh()
+	if err != nil {
+		return nil, err
+	}
+	w.current.Store(cfg)
+	return w, nil
+}
+
+// Get returns the current configuration. It must be treated as read-only, since it is shared.
+func (w *Watcher[T]) Get() *T {
+	return w.current.Load()
+}
+
+// Subscribe registers fn to be called with the old and new configurations after each successful reload.
+// Subscribers are called serially, in subscription order. It returns a function removing the subscription.
+func (w *Watcher[T]) Subscribe(fn func(old, new *T)) (unsubscribe func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	id := w.nextID
+	w.nextID++
+	w.subs = append(w.subs, subscription[T]{id: id, fn: fn})
+
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		for i, s := range w.subs {
+			if s.id == id {
+				w.subs = append(w.subs[:i:i], w.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// SubscribeField calls fn with the old and new values selected by get, when a reload changes them.
+//
+//	envutils.SubscribeField(w, func(c *Config) string { return c.LogLevel }, func(old, new string) {
+//		logger.SetLevel(new)
+//	})
+func SubscribeField[T any, V comparable](w *Watcher[T], get func(cfg *T) V, fn func(old, new V)) (unsubscribe func()) {
+	return w.Subscribe(func(oldCfg, newCfg *T) {
+		if o, n := get(oldCfg), get(newCfg); o != n {
+			fn(o, n)
+		}
+	})
+}
+
+// Reload loads and validates a fresh configuration, swaps it in and notifies subscribers.
+// If loading or validation fails, the error is logged and returned, and the current configuration is kept.
+// Its signature matches ctxutils.ReloadFunc, to reload on SIGHUP through a ctxutils.Reloader.
+func (w *Watcher[T]) Reload(ctx context.Context) error {
+	w.reloading.Lock()
+	defer w.reloading.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	cfg, err := w.loadFresh()
+	if err != nil {
+		w.lf("config reload rejected, keeping the current configuration: %v", err)
+		return err
+	}
+
+	old := w.current.Swap(cfg)
+
+	w.mu.Lock()
+	subs := make([]subscription[T], len(w.subs))
+	copy(subs, w.subs)
+	w.mu.Unlock()
+
+	for _, s := range subs {
+		s.fn(old, cfg)
+	}
+	return nil
+}
+
+// Run polls the watched files and reloads the configuration when any of them is created, modified or removed,
+// until the context is cancelled.
+func (w *Watcher[T]) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if w.filesChanged() {
+				_ = w.Reload(ctx)
+			}
+		}
+	}
+}
+
+// filesChanged reports whether a watched file changed since the last check.
+func (w *Watcher[T]) filesChanged() bool {
+	w.reloading.Lock()
+	defer w.reloading.Unlock()
+
+	changed := false
+	for _, f := range w.files {
+		stamp := statFile(f)
+		if !stamp.equal(w.stamps[f]) {
+			w.stamps[f] = stamp
+			changed = true
+		}
+	}
+	return changed
+}
+
+// loadFresh loads a new configuration and validates it.
+func (w *Watcher[T]) loadFresh() (*T, error) {
+	cfg := new(T)
+	if err := w.load(cfg); err != nil {
+		return nil, err
+	}
+	if v, ok := any(cfg).(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return nil, fmt.Errorf("envutils: invalid configuration: %w", err)
+		}
+	}
+	return cfg, nil
+}
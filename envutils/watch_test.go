@@ -0,0 +1,197 @@
+package envutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type watchedConfig struct {
+	RateLimit int    `env:"RATE_LIMIT" default:"100"`
+	LogLevel  string `env:"LOG_LEVEL" default:"info"`
+}
+
+func (c *watchedConfig) Validate() error {
+	if c.RateLimit <= 0 {
+		return errors.New("rate limit must be positive")
+	}
+	return nil
+}
+
+// jsonWatcher returns a Watcher loading watchedConfig from a JSON file.
+func jsonWatcher(t *testing.T, content string, opts ...WatchOption) (*Watcher[watchedConfig], string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	load := func(cfg *watchedConfig) error {
+		src, err := JSONFileSource(path)
+		if err != nil {
+			return err
+		}
+		return Load(cfg, WithLookuper(src))
+	}
+	w, err := NewWatcher(load, append([]WatchOption{WithWatchFiles(path)}, opts...)...)
+	require.NoError(t, err)
+	return w, path
+}
+
+type logRecorder struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (r *logRecorder) Logf(format string, v ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msgs = append(r.msgs, fmt.Sprintf(format, v...))
+}
+
+func (r *logRecorder) messages() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.msgs...)
+}
+
+func TestNewWatcher(t *testing.T) {
+	w, _ := jsonWatcher(t, `{"rate_limit": 10}`)
+
+	assert.Equal(t, &watchedConfig{RateLimit: 10, LogLevel: "info"}, w.Get())
+}
+
+func TestNewWatcher_Errors(t *testing.T) {
+	_, err := NewWatcher(func(cfg *watchedConfig) error { return errors.New("boom") })
+	assert.EqualError(t, err, "boom")
+
+	_, err = NewWatcher(func(cfg *watchedConfig) error { cfg.RateLimit = -1; return nil })
+	assert.ErrorContains(t, err, "rate limit must be positive")
+}
+
+func TestNewWatcher_DefaultLoad(t *testing.T) {
+	t.Setenv("RATE_LIMIT", "42")
+
+	w, err := NewWatcher[watchedConfig](nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, 42, w.Get().RateLimit)
+}
+
+func TestWatcher_Reload(t *testing.T) {
+	w, path := jsonWatcher(t, `{"rate_limit": 10}`)
+	first := w.Get()
+
+	var calls [][2]*watchedConfig
+	w.Subscribe(func(old, new *watchedConfig) { calls = append(calls, [2]*watchedConfig{old, new}) })
+
+	var levels []string
+	SubscribeField(w, func(c *watchedConfig) string { return c.LogLevel }, func(old, new string) {
+		levels = append(levels, old+"->"+new)
+	})
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"rate_limit": 20, "log_level": "debug"}`), 0o600))
+	require.NoError(t, w.Reload(context.Background()))
+
+	assert.Equal(t, &watchedConfig{RateLimit: 20, LogLevel: "debug"}, w.Get())
+	assert.Equal(t, 10, first.RateLimit, "the previous configuration is not mutated")
+	require.Len(t, calls, 1)
+	assert.Same(t, first, calls[0][0])
+	assert.Same(t, w.Get(), calls[0][1])
+	assert.Equal(t, []string{"info->debug"}, levels)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"rate_limit": 30, "log_level": "debug"}`), 0o600))
+	require.NoError(t, w.Reload(context.Background()))
+	assert.Len(t, calls, 2)
+	assert.Equal(t, []string{"info->debug"}, levels, "unchanged fields are not notified")
+}
+
+func TestWatcher_ReloadRejected(t *testing.T) {
+	logs := &logRecorder{}
+	w, path := jsonWatcher(t, `{"rate_limit": 10}`, WithWatchLogger(logs.Logf))
+	called := false
+	w.Subscribe(func(old, new *watchedConfig) { called = true })
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"rate_limit": 0}`), 0o600))
+	assert.Error(t, w.Reload(context.Background()))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"rate_limit": "many"}`), 0o600))
+	assert.Error(t, w.Reload(context.Background()))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{`), 0o600))
+	assert.Error(t, w.Reload(context.Background()))
+
+	assert.Equal(t, 10, w.Get().RateLimit)
+	assert.False(t, called)
+	assert.Len(t, logs.messages(), 3)
+	assert.Contains(t, logs.messages()[0], "rate limit must be positive")
+}
+
+func TestWatcher_Unsubscribe(t *testing.T) {
+	w, _ := jsonWatcher(t, `{"rate_limit": 10}`)
+	var a, b int
+	unsubscribe := w.Subscribe(func(old, new *watchedConfig) { a++ })
+	w.Subscribe(func(old, new *watchedConfig) { b++ })
+
+	unsubscribe()
+	unsubscribe()
+	require.NoError(t, w.Reload(context.Background()))
+
+	assert.Equal(t, 0, a)
+	assert.Equal(t, 1, b)
+}
+
+func TestWatcher_ReloadCancelled(t *testing.T) {
+	w, _ := jsonWatcher(t, `{"rate_limit": 10}`)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, w.Reload(ctx), context.Canceled)
+}
+
+func TestWithPollInterval_NonPositive(t *testing.T) {
+	for _, d := range []time.Duration{0, -time.Second} {
+		t.Run(d.String(), func(t *testing.T) {
+			var c watchConfig
+			WithPollInterval(d)(&c)
+			assert.Equal(t, DefaultPollInterval, c.interval)
+
+			w, _ := jsonWatcher(t, `{"rate_limit": 10}`, WithPollInterval(d))
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			assert.NotPanics(t, func() { w.Run(ctx) })
+		})
+	}
+}
+
+func TestWatcher_Run(t *testing.T) {
+	w, path := jsonWatcher(t, `{"rate_limit": 10}`, WithPollInterval(10*time.Millisecond))
+	changed := make(chan int, 1)
+	SubscribeField(w, func(c *watchedConfig) int { return c.RateLimit }, func(old, new int) { changed <- new })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+
+	// Change the size as well, in case the file system has a coarse modification time.
+	require.NoError(t, os.WriteFile(path, []byte(`{"rate_limit": 2000}`), 0o600))
+
+	select {
+	case v := <-changed:
+		assert.Equal(t, 2000, v)
+	case <-time.After(2 * time.Second):
+		t.Fatal("configuration was not reloaded")
+	}
+
+	cancel()
+	<-done
+}
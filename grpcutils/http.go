@@ -62,3 +62,43 @@ func GRPCErrorToHTTPStatus(err error) (int, error) {
 		return http.StatusInternalServerError, fmt.Errorf("unknown gRPC error code: %v", st.Code())
 	}
 }
+
+// HTTPStatusToGRPCCode converts an HTTP status code into the corresponding gRPC error code, the reverse of
+// GRPCErrorToHTTPStatus. Status codes without a specific mapping convert to codes.Unknown.
+//
+// See: https://cloud.google.com/apis/design/errors#handling_errors
+func HTTPStatusToGRPCCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound, http.StatusGone:
+		return codes.NotFound
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusRequestedRangeNotSatisfiable:
+		return codes.OutOfRange
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case 499: // Client Closed Request
+		return codes.Canceled
+	case http.StatusInternalServerError:
+		return codes.Internal
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return codes.Unavailable
+	}
+
+	if httpStatus >= 200 && httpStatus < 300 {
+		return codes.OK
+	}
+	return codes.Unknown
+}
@@ -52,3 +52,45 @@ func TestGRPCErrorToHTTPStatus(t *testing.T) {
 		})
 	}
 }
+
+// TestHTTPStatusToGRPCCode tests the HTTPStatusToGRPCCode function.
+func TestHTTPStatusToGRPCCode(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        int
+		expectedCode codes.Code
+	}{
+		{"200 OK", http.StatusOK, codes.OK},
+		{"204 No Content", http.StatusNoContent, codes.OK},
+		{"302 Found", http.StatusFound, codes.Unknown},
+		{"400 Bad Request", http.StatusBadRequest, codes.InvalidArgument},
+		{"401 Unauthorized", http.StatusUnauthorized, codes.Unauthenticated},
+		{"403 Forbidden", http.StatusForbidden, codes.PermissionDenied},
+		{"404 Not Found", http.StatusNotFound, codes.NotFound},
+		{"405 Method Not Allowed", http.StatusMethodNotAllowed, codes.Unimplemented},
+		{"408 Request Timeout", http.StatusRequestTimeout, codes.DeadlineExceeded},
+		{"409 Conflict", http.StatusConflict, codes.Aborted},
+		{"410 Gone", http.StatusGone, codes.NotFound},
+		{"412 Precondition Failed", http.StatusPreconditionFailed, codes.FailedPrecondition},
+		{"416 Range Not Satisfiable", http.StatusRequestedRangeNotSatisfiable, codes.OutOfRange},
+		{"418 I'm a teapot", http.StatusTeapot, codes.Unknown},
+		{"422 Unprocessable Entity", http.StatusUnprocessableEntity, codes.InvalidArgument},
+		{"429 Too Many Requests", http.StatusTooManyRequests, codes.ResourceExhausted},
+		{"499 Client Closed Request", 499, codes.Canceled},
+		{"500 Internal Server Error", http.StatusInternalServerError, codes.Internal},
+		{"501 Not Implemented", http.StatusNotImplemented, codes.Unimplemented},
+		{"502 Bad Gateway", http.StatusBadGateway, codes.Unavailable},
+		{"503 Service Unavailable", http.StatusServiceUnavailable, codes.Unavailable},
+		{"504 Gateway Timeout", http.StatusGatewayTimeout, codes.DeadlineExceeded},
+		{"Unknown status", 999, codes.Unknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := HTTPStatusToGRPCCode(tt.input)
+			if code != tt.expectedCode {
+				t.Errorf("HTTPStatusToGRPCCode(%v) = %v, expected %v", tt.input, code, tt.expectedCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package grpcutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Sectoid-Systems/sectoid-go-kit/logmesh"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// LevelFunc chooses the level a call is logged at from its status code.
+type LevelFunc func(code codes.Code) logmesh.LogLevel
+
+// LoggingOption configures the logging interceptors.
+type LoggingOption func(*loggingOptions)
+
+type loggingOptions struct {
+	level       LevelFunc
+	skip        map[string]bool
+	payloads    bool
+	payloadSize int
+}
+
+// WithLevelFunc sets how the level of a call is chosen from its status code. Defaults to DefaultLevel.
+func WithLevelFunc(f LevelFunc) LoggingOption {
+	return func(o *loggingOptions) {
+		o.level = f
+	}
+}
+
+// WithSkipMethods disables logging for the given full method names, e.g. "/grpc.health.v1.Health/Check".
+func WithSkipMethods(methods ...string) LoggingOption {
+	return func(o *loggingOptions) {
+		for _, m := range methods {
+			o.skip[m] = true
+		}
+	}
+}
+
+// WithPayloadLogging logs the messages of the calls as JSON, truncated to maxSize bytes; zero or less
+// means no limit. Unary payloads are added to the call log, stream messages are logged one by one at debug level.
+// Payloads may contain personal data or secrets, use with care.
+func WithPayloadLogging(maxSize int) LoggingOption {
+	return func(o *loggingOptions) {
+		o.payloads = true
+		o.payloadSize = maxSize
+	}
+}
+
+func newLoggingOptions(opts []LoggingOption) *loggingOptions {
+	o := &loggingOptions{level: DefaultLevel, skip: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// DefaultLevel logs successful calls and client errors at info level, errors that may need attention
+// at warn level, and server errors at error level.
+func DefaultLevel(code codes.Code) logmesh.LogLevel {
+	switch code {
+	case codes.OK, codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.Unauthenticated:
+		return logmesh.LogLevelInfo
+	case codes.DeadlineExceeded, codes.PermissionDenied, codes.ResourceExhausted, codes.FailedPrecondition,
+		codes.Aborted, codes.OutOfRange, codes.Unavailable:
+		return logmesh.LogLevelWarn
+	default:
+		return logmesh.LogLevelError
+	}
+}
+
+// UnaryServerLoggingInterceptor logs every unary call handled by the server, once it is done.
+//
+// The log carries the method, peer address, status code, duration, request and response sizes and
+// deadline of the call, and its error if any.
+func UnaryServerLoggingInterceptor(logger logmesh.Logger, opts ...LoggingOption) grpc.UnaryServerInterceptor {
+	o := newLoggingOptions(opts)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if o.skip[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		c := o.newCall(ctx, logger, "server unary", info.FullMethod)
+		c.peer(ctx)
+		resp, err := handler(ctx, req)
+		c.unary(req, resp, err)
+		return resp, err
+	}
+}
+
+// StreamServerLoggingInterceptor logs every streaming call handled by the server, once it is done.
+//
+// The log carries the method, peer address, status code, duration, number and size of the messages
+// sent and received, and deadline of the call, and its error if any.
+func StreamServerLoggingInterceptor(logger logmesh.Logger, opts ...LoggingOption) grpc.StreamServerInterceptor {
+	o := newLoggingOptions(opts)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if o.skip[info.FullMethod] {
+			return handler(srv, ss)
+		}
+
+		c := o.newCall(ss.Context(), logger, "server stream", info.FullMethod)
+		c.stream = true
+		c.peer(ss.Context())
+		err := handler(srv, &loggingServerStream{ServerStream: ss, call: c})
+		c.finish(err)
+		return err
+	}
+}
+
+// UnaryClientLoggingInterceptor logs every unary call made by the client, once it is done.
+//
+// The log carries the method, target and peer address, status code, duration, request and response
+// sizes and deadline of the call, and its error if any.
+func UnaryClientLoggingInterceptor(logger logmesh.Logger, opts ...LoggingOption) grpc.UnaryClientInterceptor {
+	o := newLoggingOptions(opts)
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		if o.skip[method] {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+
+		c := o.newCall(ctx, logger, "client unary", method)
+		c.logger = c.logger.With("grpc.target", cc.Target())
+		var p peer.Peer
+		err := invoker(ctx, method, req, reply, cc, append(callOpts, grpc.Peer(&p))...)
+		if p.Addr != nil {
+			c.logger = c.logger.With("peer.address", p.Addr.String())
+		}
+		c.unary(req, reply, err)
+		return err
+	}
+}
+
+// StreamClientLoggingInterceptor logs every streaming call made by the client, once it is done: when
+// receiving a message fails or reaches the end of the stream, or when the single response of a
+// client-streaming call is received.
+//
+// The log carries the method, target, status code, duration, number and size of the messages sent and
+// received, and deadline of the call, and its error if any.
+func StreamClientLoggingInterceptor(logger logmesh.Logger, opts ...LoggingOption) grpc.StreamClientInterceptor {
+	o := newLoggingOptions(opts)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if o.skip[method] {
+			return streamer(ctx, desc, cc, method, callOpts...)
+		}
+
+		c := o.newCall(ctx, logger, "client stream", method)
+		c.stream = true
+		c.logger = c.logger.With("grpc.target", cc.Target())
+		cs, err := streamer(ctx, desc, cc, method, callOpts...)
+		if err != nil {
+			c.finish(err)
+			return nil, err
+		}
+		return &loggingClientStream{ClientStream: cs, call: c, serverStreams: desc.ServerStreams}, nil
+	}
+}
+
+// call accumulates what is logged about a call.
+type call struct {
+	opts   *loggingOptions
+	logger logmesh.Logger
+	kind   string
+	method string
+	stream bool
+	start  time.Time
+
+	mu           sync.Mutex
+	sent, recv   int
+	sentBytes    int
+	recvBytes    int
+	finishedOnce sync.Once
+}
+
+func (o *loggingOptions) newCall(ctx context.Context, logger logmesh.Logger, kind, method string) *call {
+	logger = logger.With("grpc.method", method)
+	if deadline, ok := ctx.Deadline(); ok {
+		logger = logger.With("grpc.deadline", deadline.Format(time.RFC3339Nano))
+	}
+	return &call{opts: o, logger: logger, kind: kind, method: method, start: time.Now()}
+}
+
+// peer adds the address of the peer in the context, if any.
+func (c *call) peer(ctx context.Context) {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		c.logger = c.logger.With("peer.address", p.Addr.String())
+	}
+}
+
+// unary logs a unary call. The response is left out if the call failed.
+func (c *call) unary(req, resp any, err error) {
+	if err != nil {
+		resp = nil
+	}
+	c.logger = c.logger.With("grpc.request_size", strconv.Itoa(messageSize(req)))
+	if resp != nil {
+		c.logger = c.logger.With("grpc.response_size", strconv.Itoa(messageSize(resp)))
+	}
+	if c.opts.payloads {
+		c.logger = c.logger.With("grpc.request", c.payload(req))
+		if resp != nil {
+			c.logger = c.logger.With("grpc.response", c.payload(resp))
+		}
+	}
+	c.finish(err)
+}
+
+// message records a message sent or received on a stream.
+func (c *call) message(msg any, sent bool) {
+	size := messageSize(msg)
+	c.mu.Lock()
+	if sent {
+		c.sent++
+		c.sentBytes += size
+	} else {
+		c.recv++
+		c.recvBytes += size
+	}
+	c.mu.Unlock()
+
+	if c.opts.payloads {
+		direction := "received"
+		if sent {
+			direction = "sent"
+		}
+		c.logger.With("grpc.payload", c.payload(msg)).Debugf("%s %s message on %s", c.kind, direction, c.method)
+	}
+}
+
+// finish logs the call, at most once. Streams also log their message counts and sizes.
+func (c *call) finish(err error) {
+	c.finishedOnce.Do(func() {
+		code := status.Code(err)
+		logger := c.logger.With("grpc.code", code.String())
+		if c.stream {
+			c.mu.Lock()
+			logger = logger.
+				With("grpc.sent_messages", strconv.Itoa(c.sent)).
+				With("grpc.sent_size", strconv.Itoa(c.sentBytes)).
+				With("grpc.received_messages", strconv.Itoa(c.recv)).
+				With("grpc.received_size", strconv.Itoa(c.recvBytes))
+			c.mu.Unlock()
+		}
+		duration := time.Since(c.start)
+		logger = logger.With("grpc.duration", duration.String())
+		if err != nil {
+			logger = logger.With("error", err.Error())
+		}
+
+		format, args := "finished %s call %s with code %s in %s", []any{c.kind, c.method, code, duration}
+		switch c.opts.level(code) {
+		case logmesh.LogLevelDebug:
+			logger.Debugf(format, args...)
+		case logmesh.LogLevelInfo:
+			logger.Infof(format, args...)
+		case logmesh.LogLevelWarn:
+			logger.Warnf(format, args...)
+		default:
+			logger.Errorf(format, args...)
+		}
+	})
+}
+
+// payload returns the JSON of a message, truncated to the maximum payload size.
+func (c *call) payload(msg any) string {
+	var s string
+	if m, ok := msg.(proto.Message); ok {
+		b, err := protojson.Marshal(m)
+		if err != nil {
+			s = fmt.Sprintf("%v", msg)
+		} else {
+			s = string(b)
+		}
+	} else {
+		s = fmt.Sprintf("%v", msg)
+	}
+	if c.opts.payloadSize > 0 && len(s) > c.opts.payloadSize {
+		s = truncateUTF8(s, c.opts.payloadSize) + "..."
+	}
+	return s
+}
+
+// messageSize returns the encoded size of a protobuf message, or 0 for other messages.
+func messageSize(msg any) int {
+	if m, ok := msg.(proto.Message); ok {
+		return proto.Size(m)
+	}
+	return 0
+}
+
+type loggingServerStream struct {
+	grpc.ServerStream
+	call *call
+}
+
+func (s *loggingServerStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.call.message(m, true)
+	}
+	return err
+}
+
+func (s *loggingServerStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.call.message(m, false)
+	}
+	return err
+}
+
+type loggingClientStream struct {
+	grpc.ClientStream
+	call          *call
+	serverStreams bool
+}
+
+func (s *loggingClientStream) SendMsg(m any) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.call.message(m, true)
+	}
+	return err
+}
+
+func (s *loggingClientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case errors.Is(err, io.EOF):
+		s.call.finish(nil)
+	case err != nil:
+		s.call.finish(err)
+	default:
+		s.call.message(m, false)
+		if !s.serverStreams {
+			s.call.finish(nil)
+		}
+	}
+	return err
+}
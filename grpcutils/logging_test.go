@@ -0,0 +1,274 @@
+package grpcutils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Sectoid-Systems/sectoid-go-kit/logmesh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// logEntry is a log recorded by loggerMock.
+type logEntry struct {
+	level  logmesh.LogLevel
+	msg    string
+	fields map[string]string
+}
+
+// loggerMock is a logmesh.Logger recording formatted logs with their fields.
+type loggerMock struct {
+	mu      *sync.Mutex
+	entries *[]logEntry
+	fields  map[string]string
+}
+
+func newLoggerMock() *loggerMock {
+	return &loggerMock{mu: &sync.Mutex{}, entries: &[]logEntry{}, fields: map[string]string{}}
+}
+
+func (l *loggerMock) log(level logmesh.LogLevel, format string, v ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	*l.entries = append(*l.entries, logEntry{level: level, msg: fmt.Sprintf(format, v...), fields: l.fields})
+}
+
+// logs returns the recorded logs.
+func (l *loggerMock) logs() []logEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]logEntry(nil), *l.entries...)
+}
+
+func (l *loggerMock) With(key, value string) logmesh.Logger {
+	fields := map[string]string{key: value}
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+	return &loggerMock{mu: l.mu, entries: l.entries, fields: fields}
+}
+
+func (l *loggerMock) Debugf(format string, v ...any) { l.log(logmesh.LogLevelDebug, format, v...) }
+func (l *loggerMock) Infof(format string, v ...any)  { l.log(logmesh.LogLevelInfo, format, v...) }
+func (l *loggerMock) Warnf(format string, v ...any)  { l.log(logmesh.LogLevelWarn, format, v...) }
+func (l *loggerMock) Errorf(format string, v ...any) { l.log(logmesh.LogLevelError, format, v...) }
+func (l *loggerMock) Info(...any)                    {}
+func (l *loggerMock) Debug(...any)                   {}
+func (l *loggerMock) Warn(...any)                    {}
+func (l *loggerMock) Error(...any)                   {}
+func (l *loggerMock) Panicf(string, ...any)          {}
+func (l *loggerMock) DPanicf(string, ...any)         {}
+func (l *loggerMock) Child(string) logmesh.Logger    { return l }
+func (l *loggerMock) Flush()                         {}
+func (l *loggerMock) Close() error                   { return nil }
+
+// startHealthServer serves a health server on an in-memory listener and returns a client connection to it.
+func startHealthServer(t *testing.T, serverOpts []grpc.ServerOption, dialOpts ...grpc.DialOption) (*health.Server, *grpc.ClientConn) {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(serverOpts...)
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	dialOpts = append(dialOpts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.NewClient("passthrough:///bufnet", dialOpts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return hs, conn
+}
+
+func TestUnaryLoggingInterceptors(t *testing.T) {
+	serverLogs, clientLogs := newLoggerMock(), newLoggerMock()
+	_, conn := startHealthServer(t,
+		[]grpc.ServerOption{grpc.UnaryInterceptor(UnaryServerLoggingInterceptor(serverLogs, WithPayloadLogging(0)))},
+		grpc.WithUnaryInterceptor(UnaryClientLoggingInterceptor(clientLogs)))
+	client := healthpb.NewHealthClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "missing"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	logs := serverLogs.logs()
+	require.Len(t, logs, 2)
+	assert.Equal(t, logmesh.LogLevelInfo, logs[0].level)
+	assert.Regexp(t, `^finished server unary call /grpc.health.v1.Health/Check with code OK in \S+$`, logs[0].msg)
+	assert.Equal(t, "/grpc.health.v1.Health/Check", logs[0].fields["grpc.method"])
+	assert.Equal(t, "OK", logs[0].fields["grpc.code"])
+	assert.Equal(t, "bufconn", logs[0].fields["peer.address"])
+	assert.NotEmpty(t, logs[0].fields["grpc.deadline"])
+	assert.NotEmpty(t, logs[0].fields["grpc.duration"])
+	assert.Equal(t, "0", logs[0].fields["grpc.request_size"])
+	assert.Equal(t, "2", logs[0].fields["grpc.response_size"])
+	assert.Equal(t, "{}", logs[0].fields["grpc.request"])
+	assert.JSONEq(t, `{"status":"SERVING"}`, logs[0].fields["grpc.response"])
+	assert.NotContains(t, logs[0].fields, "error")
+
+	assert.Equal(t, "NotFound", logs[1].fields["grpc.code"])
+	assert.Contains(t, logs[1].fields["error"], "unknown service")
+	assert.NotContains(t, logs[1].fields, "grpc.response_size")
+	assert.JSONEq(t, `{"service":"missing"}`, logs[1].fields["grpc.request"])
+
+	logs = clientLogs.logs()
+	require.Len(t, logs, 2)
+	assert.Regexp(t, `^finished client unary call /grpc.health.v1.Health/Check with code OK`, logs[0].msg)
+	assert.Equal(t, "passthrough:///bufnet", logs[0].fields["grpc.target"])
+	assert.Equal(t, "bufconn", logs[0].fields["peer.address"])
+	assert.Equal(t, "2", logs[0].fields["grpc.response_size"])
+	assert.NotContains(t, logs[0].fields, "grpc.request", "payloads are not logged by default")
+	assert.Equal(t, "NotFound", logs[1].fields["grpc.code"])
+}
+
+func TestStreamLoggingInterceptors(t *testing.T) {
+	serverLogs, clientLogs := newLoggerMock(), newLoggerMock()
+	hs, conn := startHealthServer(t,
+		[]grpc.ServerOption{grpc.StreamInterceptor(StreamServerLoggingInterceptor(serverLogs))},
+		grpc.WithStreamInterceptor(StreamClientLoggingInterceptor(clientLogs, WithPayloadLogging(10))))
+	client := healthpb.NewHealthClient(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "db"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+	hs.SetServingStatus("db", healthpb.HealthCheckResponse_SERVING)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+	cancel()
+	_, err = stream.Recv()
+	require.Equal(t, codes.Canceled, status.Code(err))
+
+	logs := clientLogs.logs()
+	require.Len(t, logs, 4)
+	assert.Equal(t, logmesh.LogLevelDebug, logs[0].level)
+	assert.Equal(t, "client stream sent message on /grpc.health.v1.Health/Watch", logs[0].msg)
+	assert.Equal(t, `{"service"...`, logs[0].fields["grpc.payload"])
+	assert.Equal(t, "client stream received message on /grpc.health.v1.Health/Watch", logs[1].msg)
+	assert.Equal(t, `{"status":...`, logs[2].fields["grpc.payload"])
+	assert.Equal(t, "Canceled", logs[3].fields["grpc.code"])
+	assert.Equal(t, "1", logs[3].fields["grpc.sent_messages"])
+	assert.Equal(t, "2", logs[3].fields["grpc.received_messages"])
+	assert.Equal(t, "4", logs[3].fields["grpc.received_size"])
+
+	require.Eventually(t, func() bool { return len(serverLogs.logs()) == 1 }, time.Second, 10*time.Millisecond)
+	logs = serverLogs.logs()
+	assert.Regexp(t, `^finished server stream call /grpc.health.v1.Health/Watch with code Canceled`, logs[0].msg)
+	assert.Equal(t, "1", logs[0].fields["grpc.received_messages"])
+	assert.Equal(t, "2", logs[0].fields["grpc.sent_messages"])
+	assert.Equal(t, "bufconn", logs[0].fields["peer.address"])
+	assert.NotContains(t, logs[0].fields, "grpc.deadline")
+}
+
+func TestLoggingInterceptors_Options(t *testing.T) {
+	serverLogs := newLoggerMock()
+	levels := func(code codes.Code) logmesh.LogLevel {
+		if code == codes.NotFound {
+			return logmesh.LogLevelError
+		}
+		return logmesh.LogLevelDebug
+	}
+	_, conn := startHealthServer(t, []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(UnaryServerLoggingInterceptor(serverLogs, WithLevelFunc(levels))),
+		grpc.ChainStreamInterceptor(StreamServerLoggingInterceptor(serverLogs, WithSkipMethods(healthpb.Health_Watch_FullMethodName))),
+	})
+	client := healthpb.NewHealthClient(conn)
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "missing"})
+	require.Error(t, err)
+	stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+	require.NoError(t, stream.CloseSend())
+
+	logs := serverLogs.logs()
+	require.Len(t, logs, 2, "Watch is skipped")
+	assert.Equal(t, logmesh.LogLevelDebug, logs[0].level)
+	assert.Equal(t, logmesh.LogLevelError, logs[1].level)
+}
+
+func TestCallPayload_Truncated(t *testing.T) {
+	c := &call{opts: &loggingOptions{payloadSize: 5}}
+
+	assert.Equal(t, "éé...", c.payload("ééé"), "multi-byte characters are not cut")
+	assert.Equal(t, "short", c.payload("short"))
+}
+
+func TestDefaultLevel(t *testing.T) {
+	tests := []struct {
+		code     codes.Code
+		expected logmesh.LogLevel
+	}{
+		{codes.OK, logmesh.LogLevelInfo},
+		{codes.NotFound, logmesh.LogLevelInfo},
+		{codes.Canceled, logmesh.LogLevelInfo},
+		{codes.Unavailable, logmesh.LogLevelWarn},
+		{codes.DeadlineExceeded, logmesh.LogLevelWarn},
+		{codes.Internal, logmesh.LogLevelError},
+		{codes.Unknown, logmesh.LogLevelError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			assert.Equal(t, tt.expected, DefaultLevel(tt.code))
+		})
+	}
+}
+
+func TestStreamClientLoggingInterceptor_ClientStreaming(t *testing.T) {
+	logs := newLoggerMock()
+	interceptor := StreamClientLoggingInterceptor(logs)
+	desc := &grpc.StreamDesc{ClientStreams: true}
+	streamer := func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+		return &fakeClientStream{}, nil
+	}
+
+	cs, err := interceptor(context.Background(), desc, &grpc.ClientConn{}, "/svc/Upload", streamer)
+	require.NoError(t, err)
+	require.NoError(t, cs.SendMsg(&healthpb.HealthCheckRequest{Service: "a"}))
+	require.NoError(t, cs.RecvMsg(&healthpb.HealthCheckResponse{}))
+
+	entries := logs.logs()
+	require.Len(t, entries, 1, "the call is logged once its single response is received")
+	assert.True(t, strings.HasPrefix(entries[0].msg, "finished client stream call /svc/Upload with code OK"))
+	assert.Equal(t, "1", entries[0].fields["grpc.sent_messages"])
+	assert.Equal(t, "3", entries[0].fields["grpc.sent_size"])
+
+	_, err = interceptor(context.Background(), desc, &grpc.ClientConn{}, "/svc/Upload",
+		func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+			return nil, status.Error(codes.Unavailable, "no connection")
+		})
+	require.Error(t, err)
+	entries = logs.logs()
+	require.Len(t, entries, 2)
+	assert.Equal(t, logmesh.LogLevelWarn, entries[1].level)
+	assert.Equal(t, "Unavailable", entries[1].fields["grpc.code"])
+}
+
+// fakeClientStream is a grpc.ClientStream whose messages are always sent and received successfully.
+type fakeClientStream struct {
+	grpc.ClientStream
+}
+
+func (s *fakeClientStream) SendMsg(any) error { return nil }
+func (s *fakeClientStream) RecvMsg(any) error { return nil }
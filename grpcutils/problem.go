@@ -0,0 +1,196 @@
+package grpcutils
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object. Extensions are serialized as additional members.
+//
+// See: https://www.rfc-editor.org/rfc/rfc7807
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]any
+}
+
+// InvalidParam is a field violation of an errdetails.BadRequest, in the invalid-params extension member.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// MarshalJSON serializes the problem with its extension members. Extensions never override the standard members.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]any, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		switch k {
+		case "type", "title", "status", "detail", "instance":
+		default:
+			members[k] = v
+		}
+	}
+	if p.Type != "" {
+		members["type"] = p.Type
+	}
+	if p.Title != "" {
+		members["title"] = p.Title
+	}
+	if p.Status != 0 {
+		members["status"] = p.Status
+	}
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+	return json.Marshal(members)
+}
+
+// ProblemOption configures the problems built from errors.
+type ProblemOption func(*problemOptions)
+
+type problemOptions struct {
+	typeFunc     func(st *status.Status) string
+	metadataKeys []string
+	serverDetail bool
+}
+
+// WithProblemType sets the function returning the type URI of the problem of a gRPC status.
+// Empty types, the default, are serialized as "about:blank".
+func WithProblemType(f func(st *status.Status) string) ProblemOption {
+	return func(o *problemOptions) {
+		o.typeFunc = f
+	}
+}
+
+// WithProblemMetadata sets the keys of the errdetails.ErrorInfo metadata exposed in the metadata extension member.
+// By default no metadata is exposed, since it may carry internal values such as the headers of an upstream response.
+func WithProblemMetadata(keys ...string) ProblemOption {
+	return func(o *problemOptions) {
+		o.metadataKeys = keys
+	}
+}
+
+// WithServerErrorDetail exposes the message of gRPC statuses mapped to 5xx HTTP statuses as detail. By default
+// it is left out, since the message of Internal, Unknown or DataLoss statuses may reveal internals.
+func WithServerErrorDetail() ProblemOption {
+	return func(o *problemOptions) {
+		o.serverDetail = true
+	}
+}
+
+// NewProblem converts an error into a problem, with the HTTP status given by GRPCErrorToHTTPStatus and its text
+// as title. The message of gRPC statuses mapped to 4xx HTTP statuses is the detail; the message of other errors,
+// which may reveal internals, is left out unless WithServerErrorDetail is set for gRPC statuses. The following error details of gRPC statuses are translated into extension members:
+//   - errdetails.BadRequest field violations into invalid-params, a list of InvalidParam.
+//   - errdetails.ErrorInfo into reason, domain and metadata, restricted to the keys set with WithProblemMetadata.
+//   - errdetails.RetryInfo into retry-after, in seconds.
+//   - errdetails.RequestInfo into request-id, e.g. the error ID of a recovered panic.
+//
+// The gRPC code is added as grpc-code. Other details, such as errdetails.DebugInfo, are left out.
+func NewProblem(err error, opts ...ProblemOption) *Problem {
+	o := &problemOptions{typeFunc: func(*status.Status) string { return "" }}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	code, _ := GRPCErrorToHTTPStatus(err)
+	p := &Problem{
+		Title:      http.StatusText(code),
+		Status:     code,
+		Extensions: make(map[string]any),
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		p.Type = "about:blank"
+		return p
+	}
+
+	p.Type = o.typeFunc(st)
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if code < http.StatusInternalServerError || o.serverDetail {
+		p.Detail = st.Message()
+	}
+	p.Extensions["grpc-code"] = st.Code().String()
+
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.BadRequest:
+			params := make([]InvalidParam, 0, len(d.GetFieldViolations()))
+			for _, v := range d.GetFieldViolations() {
+				params = append(params, InvalidParam{Name: v.GetField(), Reason: v.GetDescription()})
+			}
+			p.Extensions["invalid-params"] = params
+		case *errdetails.ErrorInfo:
+			p.Extensions["reason"] = d.GetReason()
+			if d.GetDomain() != "" {
+				p.Extensions["domain"] = d.GetDomain()
+			}
+			if metadata := exposedMetadata(d.GetMetadata(), o.metadataKeys); len(metadata) > 0 {
+				p.Extensions["metadata"] = metadata
+			}
+		case *errdetails.RetryInfo:
+			if d.GetRetryDelay() != nil {
+				p.Extensions["retry-after"] = retryAfterSeconds(d)
+			}
+		case *errdetails.RequestInfo:
+			p.Extensions["request-id"] = d.GetRequestId()
+		}
+	}
+	return p
+}
+
+// WriteProblem writes an error as an application/problem+json response built by NewProblem, with the path of the
+// request as instance. A RetryInfo detail also sets the Retry-After header. err must not be nil.
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error, opts ...ProblemOption) {
+	p := NewProblem(err, opts...)
+	if r != nil && r.URL != nil {
+		p.Instance = r.URL.Path
+	}
+
+	body, marshalErr := json.Marshal(p)
+	if marshalErr != nil {
+		p = &Problem{Type: "about:blank", Title: http.StatusText(http.StatusInternalServerError), Status: http.StatusInternalServerError}
+		body, _ = json.Marshal(p)
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if seconds, ok := p.Extensions["retry-after"].(int64); ok {
+		w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	}
+	w.WriteHeader(p.Status)
+	w.Write(body)
+}
+
+// exposedMetadata returns the entries of metadata with the given keys.
+func exposedMetadata(metadata map[string]string, keys []string) map[string]string {
+	exposed := make(map[string]string, len(keys))
+	for _, k := range keys {
+		if v, ok := metadata[k]; ok {
+			exposed[k] = v
+		}
+	}
+	return exposed
+}
+
+// retryAfterSeconds returns the delay of a RetryInfo in whole seconds, rounded up.
+func retryAfterSeconds(info *errdetails.RetryInfo) int64 {
+	return int64(math.Ceil(max(info.GetRetryDelay().AsDuration().Seconds(), 0)))
+}
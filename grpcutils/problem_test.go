@@ -0,0 +1,166 @@
+package grpcutils
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// statusWithDetails returns the error of a status with details.
+func statusWithDetails(t *testing.T, code codes.Code, msg string, details ...protoadapt.MessageV1) error {
+	t.Helper()
+	st, err := status.New(code, msg).WithDetails(details...)
+	require.NoError(t, err)
+	return st.Err()
+}
+
+func TestWriteProblem(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           func(t *testing.T) error
+		expectedCode  int
+		expectedBody  string
+		expectedRetry string
+	}{
+		{
+			name:         "Plain error",
+			err:          func(*testing.T) error { return errors.New("connection refused to 10.0.0.1") },
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"instance":"/orders/42"}`,
+		},
+		{
+			name:         "gRPC status",
+			err:          func(*testing.T) error { return status.Error(codes.NotFound, "order 42 not found") },
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"order 42 not found","instance":"/orders/42","grpc-code":"NotFound"}`,
+		},
+		{
+			name: "Bad request",
+			err: func(t *testing.T) error {
+				return statusWithDetails(t, codes.InvalidArgument, "invalid order", &errdetails.BadRequest{
+					FieldViolations: []*errdetails.BadRequest_FieldViolation{
+						{Field: "quantity", Description: "must be positive"},
+						{Field: "items[0].sku", Description: "is required"},
+					},
+				}, &errdetails.DebugInfo{Detail: "stack trace"})
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid order","instance":"/orders/42","grpc-code":"InvalidArgument",
+				"invalid-params":[{"name":"quantity","reason":"must be positive"},{"name":"items[0].sku","reason":"is required"}]}`,
+		},
+		{
+			name: "Error info and retry info",
+			err: func(t *testing.T) error {
+				return statusWithDetails(t, codes.ResourceExhausted, "quota exceeded",
+					&errdetails.ErrorInfo{Reason: "RATE_LIMITED", Domain: "orders.example.com", Metadata: map[string]string{"limit": "100"}},
+					&errdetails.RetryInfo{RetryDelay: durationpb.New(1500 * time.Millisecond)})
+			},
+			expectedCode: http.StatusTooManyRequests,
+			expectedBody: `{"type":"about:blank","title":"Too Many Requests","status":429,"detail":"quota exceeded","instance":"/orders/42","grpc-code":"ResourceExhausted",
+				"reason":"RATE_LIMITED","domain":"orders.example.com","retry-after":2}`,
+			expectedRetry: "2",
+		},
+		{
+			name:         "Recovered panic",
+			err:          func(*testing.T) error { return PanicStatus("abc123").Err() },
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"instance":"/orders/42","grpc-code":"Internal","request-id":"abc123"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/orders/42?token=secret", nil)
+
+			WriteProblem(rec, req, tt.err(t))
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Equal(t, ProblemContentType, rec.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedRetry, rec.Header().Get("Retry-After"))
+			assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+		})
+	}
+}
+
+func TestNewProblem_Type(t *testing.T) {
+	typeFunc := func(st *status.Status) string {
+		if st.Code() == codes.NotFound {
+			return "https://example.com/problems/not-found"
+		}
+		return ""
+	}
+
+	p := NewProblem(status.Error(codes.NotFound, "missing"), WithProblemType(typeFunc))
+	assert.Equal(t, "https://example.com/problems/not-found", p.Type)
+
+	p = NewProblem(status.Error(codes.Internal, "internal"), WithProblemType(typeFunc))
+	assert.Equal(t, "about:blank", p.Type)
+}
+
+func TestNewProblem_Metadata(t *testing.T) {
+	err := statusWithDetails(t, codes.Unavailable, "upstream unavailable", &errdetails.ErrorInfo{
+		Reason:   "SERVICE_UNAVAILABLE",
+		Metadata: map[string]string{"httpStatus": "503", "Server": "internal-proxy-7"},
+	})
+
+	tests := []struct {
+		name     string
+		opts     []ProblemOption
+		expected any
+	}{
+		{"None by default", nil, nil},
+		{"Selected keys", []ProblemOption{WithProblemMetadata("httpStatus", "Missing")}, map[string]string{"httpStatus": "503"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewProblem(err, tt.opts...)
+
+			assert.Equal(t, tt.expected, p.Extensions["metadata"])
+		})
+	}
+}
+
+func TestNewProblem_ServerErrorDetail(t *testing.T) {
+	err := status.Error(codes.Internal, "pq: password authentication failed for user app at 10.0.0.5")
+
+	tests := []struct {
+		name     string
+		opts     []ProblemOption
+		expected string
+	}{
+		{"Hidden by default", nil, ""},
+		{"Opt-in", []ProblemOption{WithServerErrorDetail()}, "pq: password authentication failed for user app at 10.0.0.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewProblem(err, tt.opts...)
+
+			assert.Equal(t, http.StatusInternalServerError, p.Status)
+			assert.Equal(t, tt.expected, p.Detail)
+			assert.Equal(t, "Internal", p.Extensions["grpc-code"])
+		})
+	}
+}
+
+func TestProblem_MarshalJSON(t *testing.T) {
+	p := &Problem{Title: "Conflict", Status: http.StatusConflict, Extensions: map[string]any{"status": "overridden", "balance": 30}}
+
+	out, err := json.Marshal(p)
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"title":"Conflict","status":409,"balance":30}`, string(out))
+}
@@ -0,0 +1,123 @@
+package grpcutils
+
+import (
+	"context"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/Sectoid-Systems/sectoid-go-kit/ctxutils"
+	"github.com/Sectoid-Systems/sectoid-go-kit/logmesh"
+	"github.com/Sectoid-Systems/sectoid-go-kit/strutils"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorIDLength is the length of the generated error IDs.
+const errorIDLength = 16
+
+// RecoveryHandler is called with a panic recovered from a handler and the ID of the error. It returns the
+// error sent to the client instead of the default codes.Internal status; a nil error keeps the default.
+type RecoveryHandler func(ctx context.Context, method string, p *ctxutils.PanicError, errorID string) error
+
+// RecoveryOption configures the recovery interceptors.
+type RecoveryOption func(*recoveryOptions)
+
+type recoveryOptions struct {
+	handler RecoveryHandler
+}
+
+// WithRecoveryHandler sets a hook called with every recovered panic, after it is logged, e.g. to report it
+// or to return a custom error.
+func WithRecoveryHandler(h RecoveryHandler) RecoveryOption {
+	return func(o *recoveryOptions) {
+		o.handler = h
+	}
+}
+
+// UnaryServerRecoveryInterceptor recovers panics of unary handlers, logs them with their stack trace, and returns
+// a codes.Internal status carrying an error ID, as errdetails.RequestInfo, that clients can quote to support.
+// It should be the last interceptor of the chain so that the others see the returned status.
+func UnaryServerRecoveryInterceptor(logger logmesh.Logger, opts ...RecoveryOption) grpc.UnaryServerInterceptor {
+	o := newRecoveryOptions(opts)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				resp, err = nil, o.recovered(ctx, logger, info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerRecoveryInterceptor recovers panics of stream handlers, like UnaryServerRecoveryInterceptor.
+func StreamServerRecoveryInterceptor(logger logmesh.Logger, opts ...RecoveryOption) grpc.StreamServerInterceptor {
+	o := newRecoveryOptions(opts)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = o.recovered(ss.Context(), logger, info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+func newRecoveryOptions(opts []RecoveryOption) *recoveryOptions {
+	o := &recoveryOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// recovered logs a recovered panic and returns the error sent to the client.
+func (o *recoveryOptions) recovered(ctx context.Context, logger logmesh.Logger, method string, r any) error {
+	p := &ctxutils.PanicError{Value: r, Stack: debug.Stack()}
+	errorID := newErrorID()
+
+	logger.
+		With("grpc.method", method).
+		With("error_id", errorID).
+		With("stack", string(p.Stack)).
+		Errorf("recovered from panic in %s: %v", method, p.Value)
+
+	if o.handler != nil {
+		if err := o.handler(ctx, method, p, errorID); err != nil {
+			return err
+		}
+	}
+	return PanicStatus(errorID).Err()
+}
+
+// PanicStatus returns the codes.Internal status returned for a recovered panic, carrying the error ID
+// as errdetails.RequestInfo. It can be used by recovery handlers to wrap the default status.
+func PanicStatus(errorID string) *status.Status {
+	st := status.Newf(codes.Internal, "internal error, error ID: %s", errorID)
+	withDetails, err := st.WithDetails(&errdetails.RequestInfo{RequestId: errorID})
+	if err != nil {
+		return st
+	}
+	return withDetails
+}
+
+// ErrorID returns the error ID carried by a status returned for a recovered panic, or "" if there is none.
+func ErrorID(err error) string {
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.RequestInfo); ok {
+			return info.GetRequestId()
+		}
+	}
+	return ""
+}
+
+// newErrorID returns a random error ID, or a time-based one if randomness is unavailable.
+func newErrorID() string {
+	id, err := strutils.GenerateRandomString(errorIDLength)
+	if err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return id
+}
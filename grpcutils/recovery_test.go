@@ -0,0 +1,123 @@
+package grpcutils
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Sectoid-Systems/sectoid-go-kit/ctxutils"
+	"github.com/Sectoid-Systems/sectoid-go-kit/logmesh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errBoom = errors.New("boom")
+
+func TestUnaryServerRecoveryInterceptor(t *testing.T) {
+	logs := newLoggerMock()
+	interceptor := UnaryServerRecoveryInterceptor(logs)
+	info := &grpc.UnaryServerInfo{FullMethod: "/svc/Get"}
+
+	resp, err := interceptor(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+	assert.Empty(t, logs.logs())
+
+	resp, err = interceptor(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		panic(errBoom)
+	})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.Internal, status.Code(err))
+	id := ErrorID(err)
+	assert.Len(t, id, errorIDLength)
+	assert.Equal(t, "internal error, error ID: "+id, status.Convert(err).Message())
+
+	entries := logs.logs()
+	require.Len(t, entries, 1)
+	assert.Equal(t, logmesh.LogLevelError, entries[0].level)
+	assert.Equal(t, "recovered from panic in /svc/Get: boom", entries[0].msg)
+	assert.Equal(t, id, entries[0].fields["error_id"])
+	assert.Equal(t, "/svc/Get", entries[0].fields["grpc.method"])
+	assert.Contains(t, entries[0].fields["stack"], "recovery_test.go")
+}
+
+func TestStreamServerRecoveryInterceptor(t *testing.T) {
+	logs := newLoggerMock()
+	interceptor := StreamServerRecoveryInterceptor(logs)
+	ss := &contextServerStream{ctx: context.Background()}
+	info := &grpc.StreamServerInfo{FullMethod: "/svc/Watch"}
+
+	err := interceptor(nil, ss, info, func(any, grpc.ServerStream) error {
+		return status.Error(codes.NotFound, "missing")
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	err = interceptor(nil, ss, info, func(any, grpc.ServerStream) error {
+		var m map[string]int
+		m["nil map"]++
+		return nil
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.NotEmpty(t, ErrorID(err))
+	require.Len(t, logs.logs(), 1)
+	assert.Contains(t, logs.logs()[0].msg, "assignment to entry in nil map")
+}
+
+func TestRecoveryHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   error
+		expected codes.Code
+	}{
+		{"Custom error", status.Error(codes.Unavailable, "try again"), codes.Unavailable},
+		{"Default status", nil, codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				gotPanic *ctxutils.PanicError
+				gotID    string
+			)
+			handler := func(_ context.Context, method string, p *ctxutils.PanicError, errorID string) error {
+				assert.Equal(t, "/svc/Get", method)
+				gotPanic, gotID = p, errorID
+				return tt.result
+			}
+			interceptor := UnaryServerRecoveryInterceptor(newLoggerMock(), WithRecoveryHandler(handler))
+
+			_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/svc/Get"},
+				func(context.Context, any) (any, error) { panic(errBoom) })
+
+			assert.Equal(t, tt.expected, status.Code(err))
+			require.NotNil(t, gotPanic)
+			assert.ErrorIs(t, gotPanic, errBoom)
+			assert.NotEmpty(t, gotPanic.Stack)
+			assert.NotEmpty(t, gotID)
+			if tt.result == nil {
+				assert.Equal(t, gotID, ErrorID(err))
+			}
+		})
+	}
+}
+
+func TestErrorID(t *testing.T) {
+	assert.Equal(t, "abc", ErrorID(PanicStatus("abc").Err()))
+	assert.Empty(t, ErrorID(status.Error(codes.Internal, "internal")))
+	assert.Empty(t, ErrorID(nil))
+	assert.Empty(t, ErrorID(errBoom))
+}
+
+// contextServerStream is a grpc.ServerStream with a context.
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context { return s.ctx }
@@ -0,0 +1,237 @@
+package grpcutils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// DefaultBodySnippetSize is the default maximum number of bytes of the response body kept by HTTPResponseToStatus.
+const DefaultBodySnippetSize = 1024
+
+// sensitiveHeaders are left out of the details unless explicitly selected with WithResponseHeaders.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Cookie":              true,
+	"Proxy-Authorization": true,
+	"Set-Cookie":          true,
+}
+
+// ResponseOption configures HTTPResponseToStatus.
+type ResponseOption func(*responseOptions)
+
+type responseOptions struct {
+	bodySize int
+	headers  []string
+}
+
+// WithBodySnippetSize sets the maximum number of bytes of the response body kept in the details.
+// Zero leaves the body out.
+func WithBodySnippetSize(n int) ResponseOption {
+	return func(o *responseOptions) {
+		o.bodySize = n
+	}
+}
+
+// WithResponseHeaders selects the response headers kept in the details. By default, every header is kept
+// except Authorization, Cookie, Proxy-Authorization and Set-Cookie.
+func WithResponseHeaders(names ...string) ResponseOption {
+	return func(o *responseOptions) {
+		o.headers = names
+	}
+}
+
+// HTTPResponseToStatus converts a non-2xx HTTP response into a gRPC status, with the code given by
+// HTTPStatusToGRPCCode. It returns nil for 2xx responses.
+//
+// The status carries the following details:
+//   - errdetails.ErrorInfo, with the upstream status text as reason (e.g. SERVICE_UNAVAILABLE), the request
+//     host as domain, and the status code and response headers as metadata. Header names are converted to
+//     lowerCamelCase metadata keys, e.g. contentType for Content-Type, as required by ErrorInfo.
+//   - errdetails.DebugInfo, with the beginning of the response body.
+//   - errdetails.RetryInfo, when the response has a valid Retry-After header.
+//
+// The body is read up to the snippet size and is left readable from the start; closing it is still up to the caller.
+func HTTPResponseToStatus(resp *http.Response, opts ...ResponseOption) *status.Status {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	o := responseOptions{bodySize: DefaultBodySnippetSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	msg := fmt.Sprintf("upstream responded %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	domain := ""
+	if req := resp.Request; req != nil && req.URL != nil {
+		msg = fmt.Sprintf("%s %s%s: %s", req.Method, req.URL.Host, req.URL.Path, msg)
+		domain = req.URL.Host
+	}
+	st := status.New(HTTPStatusToGRPCCode(resp.StatusCode), msg)
+
+	metadata := responseHeaders(resp.Header, o.headers)
+	metadata["httpStatus"] = strconv.Itoa(resp.StatusCode)
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason:   statusReason(resp.StatusCode),
+		Domain:   domain,
+		Metadata: metadata,
+	}}
+
+	if snippet := bodySnippet(resp, o.bodySize); snippet != "" {
+		details = append(details, &errdetails.DebugInfo{Detail: snippet})
+	}
+
+	if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+	}
+
+	withDetails, err := st.WithDetails(details...)
+	if err != nil {
+		return st
+	}
+	return withDetails
+}
+
+// statusReason converts a status code into an UPPER_SNAKE_CASE reason, e.g. 503 into SERVICE_UNAVAILABLE.
+func statusReason(code int) string {
+	text := http.StatusText(code)
+	if text == "" {
+		return "HTTP_" + strconv.Itoa(code)
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z' || r >= '0' && r <= '9':
+			return r
+		case r == ' ' || r == '-':
+			return '_'
+		default:
+			return -1
+		}
+	}, text)
+}
+
+// responseHeaders returns the selected headers, or the non-sensitive ones when none is selected,
+// with their values joined with ", ".
+func responseHeaders(header http.Header, names []string) map[string]string {
+	metadata := make(map[string]string)
+	if len(names) == 0 {
+		for name := range header {
+			if !sensitiveHeaders[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+	}
+	for _, name := range names {
+		if values := header.Values(name); len(values) > 0 {
+			metadata[headerMetadataKey(name)] = strings.Join(values, ", ")
+		}
+	}
+	return metadata
+}
+
+// headerMetadataKey converts a header name into a lowerCamelCase key matching [a-z][a-zA-Z0-9-_]+,
+// e.g. X-Request-Id into xRequestId. Names not starting with a letter are prefixed with header.
+func headerMetadataKey(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+			if upper && b.Len() > 0 {
+				b.WriteRune(unicode.ToUpper(r))
+			} else {
+				b.WriteRune(unicode.ToLower(r))
+			}
+			upper = false
+		case r >= '0' && r <= '9':
+			if b.Len() == 0 {
+				b.WriteString("header")
+			}
+			b.WriteRune(r)
+			upper = false
+		default:
+			upper = true
+		}
+	}
+	key := b.String()
+	if len(key) < 2 {
+		key = "header" + strings.ToUpper(key)
+	}
+	return key
+}
+
+// bodySnippet reads up to n bytes of the body, as valid UTF-8, and puts them back in front of the rest of the body.
+func bodySnippet(resp *http.Response, n int) string {
+	if n <= 0 || resp.Body == nil || resp.Body == http.NoBody {
+		return ""
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(resp.Body, int64(n)+1))
+	resp.Body = readCloser{io.MultiReader(bytes.NewReader(buf), resp.Body), resp.Body}
+	if err != nil && len(buf) == 0 {
+		return ""
+	}
+
+	snippet := strings.ToValidUTF8(truncateUTF8(string(buf), n), "�")
+	if len(buf) > n {
+		snippet += "..."
+	}
+	return snippet
+}
+
+// truncateUTF8 returns the first n bytes of s at most, without cutting a multi-byte character in half.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	s = s[:n]
+	i := len(s) - 1
+	for i > 0 && i > len(s)-utf8.UTFMax && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	if i >= 0 && !utf8.FullRuneInString(s[i:]) {
+		s = s[:i]
+	}
+	return s
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// parseRetryAfter parses a Retry-After header, given either in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		// Delays that do not fit in a time.Duration are invalid rather than wrapped around.
+		if seconds < 0 || seconds > int64(math.MaxInt64/time.Second) {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,191 @@
+package grpcutils
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+)
+
+// newResponse returns a response to a GET request to https://api.example.com/v1/orders.
+func newResponse(code int, header http.Header, body string) *http.Response {
+	rec := httptest.NewRecorder()
+	for k, v := range header {
+		rec.Header()[k] = v
+	}
+	rec.WriteHeader(code)
+	rec.WriteString(body)
+	resp := rec.Result()
+	resp.Request = httptest.NewRequest(http.MethodGet, "https://api.example.com/v1/orders?token=secret", nil)
+	return resp
+}
+
+// details returns the details of the status by type.
+func details(t *testing.T, details []any) (*errdetails.ErrorInfo, *errdetails.DebugInfo, *errdetails.RetryInfo) {
+	t.Helper()
+	var (
+		info  *errdetails.ErrorInfo
+		debug *errdetails.DebugInfo
+		retry *errdetails.RetryInfo
+	)
+	for _, d := range details {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			info = d
+		case *errdetails.DebugInfo:
+			debug = d
+		case *errdetails.RetryInfo:
+			retry = d
+		default:
+			t.Fatalf("unexpected detail %T", d)
+		}
+	}
+	return info, debug, retry
+}
+
+func TestHTTPResponseToStatus(t *testing.T) {
+	header := http.Header{
+		"Content-Type": {"application/json"},
+		"Retry-After":  {"120"},
+		"Set-Cookie":   {"session=secret"},
+		"Vary":         {"Accept", "Origin"},
+	}
+	resp := newResponse(http.StatusServiceUnavailable, header, `{"error":"maintenance"}`)
+
+	st := HTTPResponseToStatus(resp)
+
+	require.NotNil(t, st)
+	assert.Equal(t, codes.Unavailable, st.Code())
+	assert.Equal(t, "GET api.example.com/v1/orders: upstream responded 503 Service Unavailable", st.Message())
+
+	info, debug, retry := details(t, st.Details())
+	require.NotNil(t, info)
+	assert.Equal(t, "SERVICE_UNAVAILABLE", info.Reason)
+	assert.Equal(t, "api.example.com", info.Domain)
+	assert.Equal(t, map[string]string{
+		"httpStatus":  "503",
+		"contentType": "application/json",
+		"retryAfter":  "120",
+		"vary":        "Accept, Origin",
+	}, info.Metadata)
+	require.NotNil(t, debug)
+	assert.Equal(t, `{"error":"maintenance"}`, debug.Detail)
+	require.NotNil(t, retry)
+	assert.Equal(t, 2*time.Minute, retry.RetryDelay.AsDuration())
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"error":"maintenance"}`, string(body), "the body is left readable")
+	assert.NoError(t, resp.Body.Close())
+}
+
+func TestHTTPResponseToStatus_TooManyRequests(t *testing.T) {
+	retryAt := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	resp := newResponse(http.StatusTooManyRequests, http.Header{"Retry-After": {retryAt}}, "")
+
+	st := HTTPResponseToStatus(resp)
+
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	info, debug, retry := details(t, st.Details())
+	assert.Equal(t, "TOO_MANY_REQUESTS", info.Reason)
+	assert.Nil(t, debug, "empty bodies are left out")
+	require.NotNil(t, retry)
+	assert.InDelta(t, time.Hour, retry.RetryDelay.AsDuration(), float64(2*time.Second))
+}
+
+func TestHTTPResponseToStatus_Success(t *testing.T) {
+	st := HTTPResponseToStatus(newResponse(http.StatusCreated, nil, "created"))
+	assert.Nil(t, st)
+	assert.NoError(t, st.Err())
+}
+
+func TestHTTPResponseToStatus_Options(t *testing.T) {
+	header := http.Header{"Set-Cookie": {"session=secret"}, "X-Request-Id": {"abc"}, "Vary": {"Accept"}}
+	body := strings.Repeat("é", 10)
+	resp := newResponse(http.StatusBadRequest, header, body)
+
+	st := HTTPResponseToStatus(resp, WithBodySnippetSize(5), WithResponseHeaders("set-cookie", "x-request-id", "missing"))
+
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	info, debug, retry := details(t, st.Details())
+	assert.Equal(t, map[string]string{"httpStatus": "400", "setCookie": "session=secret", "xRequestId": "abc"}, info.Metadata)
+	assert.Equal(t, "éé...", debug.Detail, "multi-byte characters are not cut")
+	assert.Nil(t, retry)
+
+	rest, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(rest))
+
+	st = HTTPResponseToStatus(newResponse(http.StatusBadRequest, nil, "body"), WithBodySnippetSize(0))
+	_, debug, _ = details(t, st.Details())
+	assert.Nil(t, debug)
+}
+
+func TestHTTPResponseToStatus_InvalidUTF8(t *testing.T) {
+	resp := newResponse(http.StatusInternalServerError, nil, "bad \xff byte")
+	resp.Request = nil
+
+	st := HTTPResponseToStatus(resp)
+
+	assert.Equal(t, codes.Internal, st.Code())
+	assert.Equal(t, "upstream responded 500 Internal Server Error", st.Message())
+	info, debug, _ := details(t, st.Details())
+	assert.Empty(t, info.Domain)
+	assert.Equal(t, "bad � byte", debug.Detail)
+}
+
+func TestHeaderMetadataKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"Content-Type", "contentType"},
+		{"x-request-id", "xRequestId"},
+		{"ETag", "etag"},
+		{"X", "headerX"},
+		{"1-Header", "header1Header"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := headerMetadataKey(tt.name)
+			assert.Equal(t, tt.expected, key)
+			assert.Regexp(t, `^[a-z][a-zA-Z0-9-_]+$`, key)
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"Empty", "", 0, false},
+		{"Seconds", " 30 ", 30 * time.Second, true},
+		{"Zero", "0", 0, true},
+		{"Negative", "-1", 0, false},
+		{"HTTP date", "Sat, 01 Jun 2024 12:01:30 GMT", 90 * time.Second, true},
+		{"Past date", "Sat, 01 Jun 2024 11:00:00 GMT", 0, true},
+		{"Invalid", "soon", 0, false},
+		{"Overflowing seconds", "99999999999", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, ok := parseRetryAfter(tt.value, now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, d)
+		})
+	}
+}
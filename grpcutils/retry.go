@@ -0,0 +1,169 @@
+package grpcutils
+
+import (
+	"context"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/Sectoid-Systems/sectoid-go-kit/misc"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// AttemptMetadataKey is the outgoing metadata key carrying the attempt number of a call, starting at 1.
+const AttemptMetadataKey = "x-retry-attempt"
+
+// DefaultRetryCodes are the status codes retried by default.
+var DefaultRetryCodes = []codes.Code{codes.Unavailable, codes.ResourceExhausted, codes.Aborted}
+
+// RetryOption configures the retry interceptor.
+type RetryOption func(*retryOptions)
+
+type retryOptions struct {
+	maxAttempts    int
+	codes          map[codes.Code]bool
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	maxRetryInfo   time.Duration
+	jitter         float64
+	idempotent     func(method string) bool
+	lf             misc.LoggerFunc
+}
+
+// WithMaxAttempts sets the maximum number of attempts of a call, including the first one. Defaults to 3.
+func WithMaxAttempts(n int) RetryOption {
+	return func(o *retryOptions) {
+		o.maxAttempts = n
+	}
+}
+
+// WithRetryCodes sets the status codes that are retried. Defaults to DefaultRetryCodes.
+func WithRetryCodes(cs ...codes.Code) RetryOption {
+	return func(o *retryOptions) {
+		o.codes = make(map[codes.Code]bool, len(cs))
+		for _, c := range cs {
+			o.codes[c] = true
+		}
+	}
+}
+
+// WithRetryBackoff sets the delay before the first retry and the maximum delay. The delay doubles with every retry.
+// Defaults to 100ms and 5s.
+func WithRetryBackoff(initial, max time.Duration) RetryOption {
+	return func(o *retryOptions) {
+		o.initialBackoff = initial
+		o.maxBackoff = max
+	}
+}
+
+// WithMaxRetryInfoDelay caps the delay requested by the errdetails.RetryInfo of an error, so that a server cannot
+// make the client wait indefinitely. Defaults to 30s.
+func WithMaxRetryInfoDelay(max time.Duration) RetryOption {
+	return func(o *retryOptions) {
+		o.maxRetryInfo = max
+	}
+}
+
+// WithRetryJitter sets the fraction of the delay randomly added or removed, between 0 and 1. Defaults to 0.2.
+func WithRetryJitter(fraction float64) RetryOption {
+	return func(o *retryOptions) {
+		o.jitter = min(max(fraction, 0), 1)
+	}
+}
+
+// WithIdempotentMethods marks the given full method names, e.g. "/orders.v1.Orders/GetOrder", as safe to retry.
+func WithIdempotentMethods(methods ...string) RetryOption {
+	return func(o *retryOptions) {
+		set := make(map[string]bool, len(methods))
+		for _, m := range methods {
+			set[m] = true
+		}
+		o.idempotent = func(method string) bool { return set[method] }
+	}
+}
+
+// WithIdempotentFunc sets the function telling whether a full method name is safe to retry.
+func WithIdempotentFunc(f func(method string) bool) RetryOption {
+	return func(o *retryOptions) {
+		o.idempotent = f
+	}
+}
+
+// WithRetryLogger sets the function used to report retried attempts.
+func WithRetryLogger(lf misc.LoggerFunc) RetryOption {
+	return func(o *retryOptions) {
+		o.lf = lf
+	}
+}
+
+// UnaryClientRetryInterceptor retries the unary calls of idempotent methods failing with one of the retry codes.
+// No method is retried unless marked with WithIdempotentMethods or WithIdempotentFunc.
+//
+// Retries are delayed with exponential backoff and jitter, or by the delay of the errdetails.RetryInfo of the
+// error when the server sends one, up to WithMaxRetryInfoDelay. A retry that could not complete before the deadline of the caller's context
+// is not attempted, and the last error is returned. Every attempt carries its number in the outgoing metadata,
+// under AttemptMetadataKey.
+func UnaryClientRetryInterceptor(opts ...RetryOption) grpc.UnaryClientInterceptor {
+	o := &retryOptions{
+		maxAttempts:    3,
+		initialBackoff: 100 * time.Millisecond,
+		maxBackoff:     5 * time.Second,
+		maxRetryInfo:   30 * time.Second,
+		jitter:         0.2,
+		idempotent:     func(string) bool { return false },
+		lf:             func(string, ...any) {},
+	}
+	WithRetryCodes(DefaultRetryCodes...)(o)
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		if !o.idempotent(method) {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+
+		for attempt := 1; ; attempt++ {
+			attemptCtx := metadata.AppendToOutgoingContext(ctx, AttemptMetadataKey, strconv.Itoa(attempt))
+			err := invoker(attemptCtx, method, req, reply, cc, callOpts...)
+			if err == nil || attempt >= o.maxAttempts || !o.codes[status.Code(err)] {
+				return err
+			}
+
+			delay := o.delay(attempt, err)
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+				return err
+			}
+			o.lf("grpcutils: attempt %d of %s failed, retrying in %s: %v", attempt, method, delay, err)
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// delay returns the delay before the retry following the given attempt: the delay of the RetryInfo of the error,
+// if any, capped by maxRetryInfo, or else the backoff with jitter.
+func (o *retryOptions) delay(attempt int, err error) time.Duration {
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok && info.GetRetryDelay() != nil {
+			return min(max(info.GetRetryDelay().AsDuration(), 0), o.maxRetryInfo)
+		}
+	}
+
+	delay := o.initialBackoff
+	for i := 1; i < attempt && delay < o.maxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, o.maxBackoff)
+	return delay + time.Duration(float64(delay)*o.jitter*(2*rand.Float64()-1))
+}
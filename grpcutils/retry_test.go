@@ -0,0 +1,166 @@
+package grpcutils
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const getMethod = "/orders.v1.Orders/GetOrder"
+
+// fakeInvoker returns the errors in order, then succeeds, and records the attempt metadata of every call.
+type fakeInvoker struct {
+	errs     []error
+	attempts []string
+	times    []time.Time
+}
+
+func (f *fakeInvoker) invoke(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	f.attempts = append(f.attempts, md.Get(AttemptMetadataKey)...)
+	f.times = append(f.times, time.Now())
+	if len(f.times) <= len(f.errs) {
+		return f.errs[len(f.times)-1]
+	}
+	return nil
+}
+
+func TestUnaryClientRetryInterceptor(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	tests := []struct {
+		name             string
+		method           string
+		errs             []error
+		expectedCode     codes.Code
+		expectedAttempts []string
+	}{
+		{"Success", getMethod, nil, codes.OK, []string{"1"}},
+		{"Retried until success", getMethod, []error{unavailable, status.Error(codes.Aborted, "aborted")}, codes.OK, []string{"1", "2", "3"}},
+		{"Max attempts", getMethod, []error{unavailable, unavailable, unavailable, unavailable}, codes.Unavailable, []string{"1", "2", "3"}},
+		{"Code not retried", getMethod, []error{status.Error(codes.Internal, "internal")}, codes.Internal, []string{"1"}},
+		{"Not idempotent", "/orders.v1.Orders/CreateOrder", []error{unavailable}, codes.Unavailable, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := &fakeInvoker{errs: tt.errs}
+			var logs []string
+			interceptor := UnaryClientRetryInterceptor(
+				WithIdempotentMethods(getMethod),
+				WithRetryBackoff(time.Millisecond, 2*time.Millisecond),
+				WithRetryLogger(func(format string, v ...any) { logs = append(logs, fmt.Sprintf(format, v...)) }))
+
+			err := interceptor(context.Background(), tt.method, nil, nil, nil, inv.invoke)
+
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+			assert.Equal(t, tt.expectedAttempts, inv.attempts)
+			if len(tt.expectedAttempts) > 1 {
+				assert.Len(t, logs, len(tt.expectedAttempts)-1)
+				assert.Contains(t, logs[0], "grpcutils: attempt 1 of "+getMethod+" failed, retrying in ")
+			}
+		})
+	}
+}
+
+func TestUnaryClientRetryInterceptor_Options(t *testing.T) {
+	inv := &fakeInvoker{errs: []error{
+		status.Error(codes.Internal, "internal"),
+		status.Error(codes.Internal, "internal"),
+		status.Error(codes.Unavailable, "unavailable"),
+	}}
+	interceptor := UnaryClientRetryInterceptor(
+		WithIdempotentFunc(func(string) bool { return true }),
+		WithRetryCodes(codes.Internal),
+		WithMaxAttempts(5),
+		WithRetryBackoff(time.Millisecond, time.Millisecond))
+
+	err := interceptor(context.Background(), "/any/Method", nil, nil, nil, inv.invoke)
+
+	assert.Equal(t, codes.Unavailable, status.Code(err), "Unavailable is no longer retried")
+	assert.Equal(t, []string{"1", "2", "3"}, inv.attempts)
+}
+
+func TestUnaryClientRetryInterceptor_RetryInfo(t *testing.T) {
+	st, err := status.New(codes.ResourceExhausted, "slow down").
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(50 * time.Millisecond)})
+	require.NoError(t, err)
+	inv := &fakeInvoker{errs: []error{st.Err()}}
+	interceptor := UnaryClientRetryInterceptor(WithIdempotentMethods(getMethod), WithRetryBackoff(time.Millisecond, time.Millisecond))
+
+	require.NoError(t, interceptor(context.Background(), getMethod, nil, nil, nil, inv.invoke))
+
+	require.Len(t, inv.times, 2)
+	assert.GreaterOrEqual(t, inv.times[1].Sub(inv.times[0]), 50*time.Millisecond)
+}
+
+func TestUnaryClientRetryInterceptor_RetryInfoCapped(t *testing.T) {
+	st, err := status.New(codes.Unavailable, "come back tomorrow").
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(24 * time.Hour)})
+	require.NoError(t, err)
+	inv := &fakeInvoker{errs: []error{st.Err()}}
+	interceptor := UnaryClientRetryInterceptor(WithIdempotentMethods(getMethod), WithMaxRetryInfoDelay(20*time.Millisecond))
+
+	start := time.Now()
+	require.NoError(t, interceptor(context.Background(), getMethod, nil, nil, nil, inv.invoke))
+
+	assert.Len(t, inv.times, 2)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestUnaryClientRetryInterceptor_Deadline(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	interceptor := UnaryClientRetryInterceptor(WithIdempotentMethods(getMethod), WithRetryBackoff(time.Second, time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	inv := &fakeInvoker{errs: []error{unavailable}}
+	start := time.Now()
+
+	err := interceptor(ctx, getMethod, nil, nil, nil, inv.invoke)
+
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, []string{"1"}, inv.attempts, "the retry would exceed the deadline")
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	inv = &fakeInvoker{errs: []error{unavailable}}
+	err = interceptor(ctx, getMethod, nil, nil, nil, inv.invoke)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Len(t, inv.attempts, 1)
+}
+
+func TestRetryDelay(t *testing.T) {
+	o := &retryOptions{initialBackoff: 100 * time.Millisecond, maxBackoff: time.Second, jitter: 0.2}
+	err := status.Error(codes.Unavailable, "unavailable")
+
+	tests := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{5, time.Second},
+		{10, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.attempt), func(t *testing.T) {
+			for range 20 {
+				assert.InDelta(t, tt.expected, o.delay(tt.attempt, err), float64(tt.expected)*0.2)
+			}
+		})
+	}
+}
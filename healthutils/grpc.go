@@ -0,0 +1,85 @@
+package healthutils
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultWatchInterval is how often Watch re-evaluates the checks of a watched service.
+const DefaultWatchInterval = 5 * time.Second
+
+// grpcServer implements grpc_health_v1.HealthServer on top of a Registry.
+type grpcServer struct {
+	healthpb.UnimplementedHealthServer
+	registry      *Registry
+	watchInterval time.Duration
+}
+
+// GRPCServer returns a grpc_health_v1.HealthServer fed by the registry readiness checks.
+// An empty service name reports the overall readiness; any other name reports the readiness check with that name.
+func (r *Registry) GRPCServer() healthpb.HealthServer {
+	return &grpcServer{registry: r, watchInterval: DefaultWatchInterval}
+}
+
+// Check implements grpc_health_v1.HealthServer.
+func (s *grpcServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	st, ok := s.servingStatus(ctx, req.GetService())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.GetService())
+	}
+	return &healthpb.HealthCheckResponse{Status: st}, nil
+}
+
+// Watch implements grpc_health_v1.HealthServer. The status is re-evaluated periodically and
+// as soon as shutdown begins; a message is sent whenever it changes.
+func (s *grpcServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	changed, unsubscribe := s.registry.subscribe()
+	defer unsubscribe()
+
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_ServingStatus(-1)
+	for {
+		st, ok := s.servingStatus(stream.Context(), req.GetService())
+		if !ok {
+			st = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		if st != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: st}); err != nil {
+				return err
+			}
+			last = st
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-ticker.C:
+		case <-changed:
+		}
+	}
+}
+
+// servingStatus evaluates the readiness of the whole registry or of a single named check.
+func (s *grpcServer) servingStatus(ctx context.Context, service string) (healthpb.HealthCheckResponse_ServingStatus, bool) {
+	if service == "" {
+		if s.registry.Ready(ctx).Status == StatusFail {
+			return healthpb.HealthCheckResponse_NOT_SERVING, true
+		}
+		return healthpb.HealthCheckResponse_SERVING, true
+	}
+
+	res, ok := s.registry.CheckNamed(ctx, service)
+	if !ok {
+		return healthpb.HealthCheckResponse_SERVICE_UNKNOWN, false
+	}
+	if res.Status == StatusFail {
+		return healthpb.HealthCheckResponse_NOT_SERVING, true
+	}
+	return healthpb.HealthCheckResponse_SERVING, true
+}
@@ -0,0 +1,81 @@
+package healthutils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// watchStream is a grpc_health_v1.Health_WatchServer collecting sent responses.
+type watchStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan healthpb.HealthCheckResponse_ServingStatus
+}
+
+func (w *watchStream) Context() context.Context { return w.ctx }
+
+func (w *watchStream) Send(res *healthpb.HealthCheckResponse) error {
+	w.sent <- res.GetStatus()
+	return nil
+}
+
+func TestGRPCServer_Check(t *testing.T) {
+	r := NewRegistry()
+	r.AddReadinessCheck(Check{Name: "db", Func: passing, Critical: true})
+	r.AddReadinessCheck(Check{Name: "search", Func: failing})
+	srv := r.GRPCServer()
+
+	tests := []struct {
+		name     string
+		service  string
+		expected healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{"Overall readiness ignores non-critical failures", "", healthpb.HealthCheckResponse_SERVING},
+		{"Named passing check", "db", healthpb.HealthCheckResponse_SERVING},
+		{"Named failing check", "search", healthpb.HealthCheckResponse_NOT_SERVING},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := srv.Check(context.Background(), &healthpb.HealthCheckRequest{Service: tt.service})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, res.GetStatus())
+		})
+	}
+
+	_, err := srv.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGRPCServer_WatchReportsDraining(t *testing.T) {
+	r := NewRegistry()
+	r.AddReadinessCheck(Check{Name: "db", Func: passing, Critical: true})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &watchStream{ctx: ctx, sent: make(chan healthpb.HealthCheckResponse_ServingStatus, 4)}
+
+	done := make(chan error, 1)
+	go func() { done <- r.GRPCServer().Watch(&healthpb.HealthCheckRequest{}, stream) }()
+
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, <-stream.sent)
+
+	require.NoError(t, r.Shutdown(context.Background()))
+	select {
+	case st := <-stream.sent:
+		assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, st)
+	case <-time.After(time.Second):
+		t.Fatal("watch did not report the draining status")
+	}
+
+	cancel()
+	assert.Equal(t, codes.Canceled, status.Code(<-done))
+}
@@ -0,0 +1,42 @@
+package healthutils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// Handler returns an http.Handler serving the liveness report on /livez and the readiness report on /readyz.
+func (r *Registry) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/livez", r.LivezHandler())
+	mux.Handle("/readyz", r.ReadyzHandler())
+	return mux
+}
+
+// LivezHandler returns an http.Handler serving the liveness report as JSON.
+func (r *Registry) LivezHandler() http.Handler {
+	return reportHandler(r.Live)
+}
+
+// ReadyzHandler returns an http.Handler serving the readiness report as JSON.
+func (r *Registry) ReadyzHandler() http.Handler {
+	return reportHandler(r.Ready)
+}
+
+// reportHandler writes the report with 200 OK when it passes or warns, and 503 Service Unavailable when it fails.
+func reportHandler(report func(ctx context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rep := report(req.Context())
+
+		code := http.StatusOK
+		if rep.Status == StatusFail {
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(rep)
+	})
+}
@@ -0,0 +1,55 @@
+package healthutils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Handler(t *testing.T) {
+	r := NewRegistry()
+	r.AddLivenessCheck(Check{Name: "loop", Func: passing, Critical: true})
+	r.AddReadinessCheck(Check{Name: "db", Func: failing, Critical: true})
+
+	tests := []struct {
+		name         string
+		path         string
+		expectedCode int
+		expected     Status
+	}{
+		{"Liveness passes", "/livez", http.StatusOK, StatusPass},
+		{"Readiness fails", "/readyz", http.StatusServiceUnavailable, StatusFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var report Report
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+			assert.Equal(t, tt.expected, report.Status)
+			assert.Len(t, report.Checks, 1)
+		})
+	}
+}
+
+func TestRegistry_ReadyzWhileDraining(t *testing.T) {
+	r := NewRegistry()
+	r.AddReadinessCheck(Check{Name: "db", Func: passing, Critical: true})
+	require.NoError(t, r.Shutdown(context.Background()))
+
+	rec := httptest.NewRecorder()
+	r.ReadyzHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), ErrDraining.Error())
+}
@@ -0,0 +1,311 @@
+// Package healthutils provides a registry of liveness and readiness checks exposed over HTTP and gRPC,
+// tied to the ctxutils lifecycle so readiness flips before the service stops serving.
+package healthutils
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Sectoid-Systems/sectoid-go-kit/ctxutils"
+)
+
+// Status is the outcome of a check or of a whole report.
+type Status string
+
+const (
+	// StatusPass means every check succeeded.
+	StatusPass Status = "pass"
+	// StatusWarn means only non-critical checks failed.
+	StatusWarn Status = "warn"
+	// StatusFail means at least one critical check failed or the service is draining.
+	StatusFail Status = "fail"
+)
+
+// ErrDraining is reported by readiness once shutdown has begun.
+var ErrDraining = errors.New("service is shutting down")
+
+// CheckFunc defines the function signature for health checks.
+type CheckFunc func(ctx context.Context) error
+
+// Check describes a named health check.
+type Check struct {
+	// Name identifies the check in reports and is the gRPC service name it answers for.
+	Name string
+	// Func performs the check.
+	Func CheckFunc
+	// Timeout bounds each execution. Defaults to the registry default timeout.
+	Timeout time.Duration
+	// CacheTTL reuses the last result for this long. Zero disables caching.
+	CacheTTL time.Duration
+	// Critical checks turn the report to StatusFail when they fail; others only produce StatusWarn.
+	Critical bool
+}
+
+// CheckResult is the outcome of a single check.
+type CheckResult struct {
+	Name     string `json:"name"`
+	Status   Status `json:"status"`
+	Critical bool   `json:"critical"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+	Cached   bool   `json:"cached,omitempty"`
+}
+
+// Report aggregates the results of a set of checks.
+type Report struct {
+	Status Status        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+type registeredCheck struct {
+	Check
+	mu       sync.Mutex
+	last     CheckResult
+	lastTime time.Time
+}
+
+// Option configures a Registry.
+type Option func(*Registry)
+
+// WithDrainDelay sets how long Shutdown keeps the service serving after readiness flips to "not ready",
+// giving load balancers time to stop routing traffic.
+func WithDrainDelay(d time.Duration) Option {
+	return func(r *Registry) {
+		r.drainDelay = d
+	}
+}
+
+// WithDefaultTimeout sets the timeout of checks registered without one. Defaults to 5s.
+func WithDefaultTimeout(d time.Duration) Option {
+	return func(r *Registry) {
+		r.defaultTimeout = d
+	}
+}
+
+// Registry holds named liveness and readiness checks.
+type Registry struct {
+	mu             sync.RWMutex
+	liveness       []*registeredCheck
+	readiness      []*registeredCheck
+	draining       bool
+	drainDelay     time.Duration
+	defaultTimeout time.Duration
+	watchers       []chan struct{}
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry(opts ...Option) *Registry {
+	r := &Registry{
+		defaultTimeout: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// AddLivenessCheck registers a check reported by Live. Liveness checks should only fail when
+// the process must be restarted.
+func (r *Registry) AddLivenessCheck(check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.liveness = append(r.liveness, r.newCheck(check))
+}
+
+// AddReadinessCheck registers a check reported by Ready.
+func (r *Registry) AddReadinessCheck(check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.readiness = append(r.readiness, r.newCheck(check))
+}
+
+// Live runs the liveness checks.
+func (r *Registry) Live(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := r.liveness
+	r.mu.RUnlock()
+
+	return runChecks(ctx, checks)
+}
+
+// Ready runs the readiness checks. Once shutdown has begun, the report fails regardless of the checks.
+func (r *Registry) Ready(ctx context.Context) Report {
+	r.mu.RLock()
+	checks, draining := r.readiness, r.draining
+	r.mu.RUnlock()
+
+	if draining {
+		return Report{
+			Status: StatusFail,
+			Checks: []CheckResult{{Name: "shutdown", Status: StatusFail, Critical: true, Error: ErrDraining.Error()}},
+		}
+	}
+
+	return runChecks(ctx, checks)
+}
+
+// CheckNamed runs the readiness check with the given name. It returns false if no such check exists.
+func (r *Registry) CheckNamed(ctx context.Context, name string) (CheckResult, bool) {
+	r.mu.RLock()
+	checks, draining := r.readiness, r.draining
+	r.mu.RUnlock()
+
+	for _, c := range checks {
+		if c.Name == name {
+			if draining {
+				return CheckResult{Name: name, Status: StatusFail, Critical: c.Critical, Error: ErrDraining.Error()}, true
+			}
+			return c.run(ctx), true
+		}
+	}
+	return CheckResult{}, false
+}
+
+// IsDraining reports whether shutdown has begun.
+func (r *Registry) IsDraining() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.draining
+}
+
+// Shutdown flips readiness to "not ready" and waits for the drain delay, or until the context is done.
+func (r *Registry) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	r.draining = true
+	watchers := r.watchers
+	r.mu.Unlock()
+
+	for _, w := range watchers {
+		select {
+		case w <- struct{}{}:
+		default:
+		}
+	}
+
+	if r.drainDelay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(r.drainDelay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// LifecycleHook returns a hook of the traffic phase with priority math.MaxInt that calls Shutdown, so readiness is
+// "not ready" and the drain delay has elapsed before servers stop accepting traffic. It is stopped before every other
+// hook of the traffic phase, except hooks also with priority math.MaxInt registered after it: ties are broken by
+// registration order.
+func (r *Registry) LifecycleHook() ctxutils.Hook {
+	return ctxutils.Hook{
+		Name:     "health",
+		Phase:    ctxutils.PhaseTraffic,
+		Priority: math.MaxInt,
+		OnStop:   r.Shutdown,
+	}
+}
+
+func (r *Registry) newCheck(check Check) *registeredCheck {
+	if check.Timeout <= 0 {
+		check.Timeout = r.defaultTimeout
+	}
+	return &registeredCheck{Check: check}
+}
+
+// subscribe returns a channel notified when the draining state changes, and a function to unsubscribe.
+func (r *Registry) subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	r.mu.Lock()
+	r.watchers = append(r.watchers, ch)
+	r.mu.Unlock()
+
+	return ch, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for i, w := range r.watchers {
+			if w == ch {
+				r.watchers = append(r.watchers[:i], r.watchers[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// runChecks runs the checks concurrently and aggregates their results.
+func runChecks(ctx context.Context, checks []*registeredCheck) Report {
+	results := make([]CheckResult, len(checks))
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusPass, Checks: results}
+	for _, res := range results {
+		if res.Status != StatusFail {
+			continue
+		}
+		if res.Critical {
+			report.Status = StatusFail
+		} else if report.Status == StatusPass {
+			report.Status = StatusWarn
+		}
+	}
+	return report
+}
+
+// run executes the check, honoring its timeout and cache.
+func (c *registeredCheck) run(ctx context.Context) CheckResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.CacheTTL > 0 && !c.lastTime.IsZero() && time.Since(c.lastTime) < c.CacheTTL {
+		res := c.last
+		res.Cached = true
+		return res
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Func(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := CheckResult{
+		Name:     c.Name,
+		Status:   StatusPass,
+		Critical: c.Critical,
+		Duration: time.Since(start).String(),
+	}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+
+	c.last, c.lastTime = res, time.Now()
+	return res
+}
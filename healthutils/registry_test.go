@@ -0,0 +1,136 @@
+package healthutils
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Sectoid-Systems/sectoid-go-kit/ctxutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func passing(context.Context) error { return nil }
+
+func failing(context.Context) error { return errors.New("unreachable") }
+
+func TestRegistry_ReportStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		checks   []Check
+		expected Status
+	}{
+		{"No checks", nil, StatusPass},
+		{"All passing", []Check{{Name: "db", Func: passing, Critical: true}}, StatusPass},
+		{"Non-critical failure", []Check{{Name: "db", Func: passing, Critical: true}, {Name: "cache", Func: failing}}, StatusWarn},
+		{"Critical failure", []Check{{Name: "db", Func: failing, Critical: true}, {Name: "cache", Func: failing}}, StatusFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			for _, c := range tt.checks {
+				r.AddReadinessCheck(c)
+			}
+
+			report := r.Ready(context.Background())
+
+			assert.Equal(t, tt.expected, report.Status)
+			assert.Len(t, report.Checks, len(tt.checks))
+		})
+	}
+}
+
+func TestRegistry_LivenessAndReadinessAreSeparate(t *testing.T) {
+	r := NewRegistry()
+	r.AddLivenessCheck(Check{Name: "deadlock", Func: passing, Critical: true})
+	r.AddReadinessCheck(Check{Name: "db", Func: failing, Critical: true})
+
+	assert.Equal(t, StatusPass, r.Live(context.Background()).Status)
+	assert.Equal(t, StatusFail, r.Ready(context.Background()).Status)
+}
+
+func TestRegistry_CheckTimeout(t *testing.T) {
+	r := NewRegistry(WithDefaultTimeout(20 * time.Millisecond))
+	r.AddReadinessCheck(Check{Name: "slow", Critical: true, Func: func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}})
+
+	start := time.Now()
+	report := r.Ready(context.Background())
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[0].Error)
+}
+
+func TestRegistry_CacheTTL(t *testing.T) {
+	r := NewRegistry()
+	var calls atomic.Int32
+	r.AddReadinessCheck(Check{Name: "db", CacheTTL: time.Minute, Func: func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	}})
+
+	first := r.Ready(context.Background())
+	second := r.Ready(context.Background())
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.False(t, first.Checks[0].Cached)
+	assert.True(t, second.Checks[0].Cached)
+}
+
+func TestRegistry_ShutdownDrains(t *testing.T) {
+	r := NewRegistry(WithDrainDelay(50 * time.Millisecond))
+	r.AddReadinessCheck(Check{Name: "db", Func: passing, Critical: true})
+	r.AddLivenessCheck(Check{Name: "loop", Func: passing, Critical: true})
+
+	lc := ctxutils.NewLifecycle()
+	stoppedWhileDraining := false
+	require.NoError(t, lc.Append(ctxutils.Hook{Name: "http", Phase: ctxutils.PhaseTraffic, OnStop: func(context.Context) error {
+		stoppedWhileDraining = r.IsDraining()
+		return nil
+	}}))
+	require.NoError(t, lc.Append(r.LifecycleHook()))
+
+	start := time.Now()
+	require.NoError(t, lc.Stop(context.Background()))
+
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond, "shutdown should wait for the drain delay")
+	assert.True(t, stoppedWhileDraining, "readiness must flip before servers stop")
+	assert.Equal(t, StatusFail, r.Ready(context.Background()).Status)
+	assert.Equal(t, StatusPass, r.Live(context.Background()).Status, "draining must not affect liveness")
+}
+
+func TestRegistry_LifecycleHookStopsBeforeNegativePriorities(t *testing.T) {
+	tests := []struct {
+		name     string
+		priority int
+	}{
+		{"Negative priority", -10},
+		{"Minimum priority", math.MinInt},
+		{"Zero priority", 0},
+		{"Maximum priority registered before", math.MaxInt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry(WithDrainDelay(time.Millisecond))
+			lc := ctxutils.NewLifecycle()
+			stoppedWhileDraining := false
+			require.NoError(t, lc.Append(ctxutils.Hook{Name: "http", Phase: ctxutils.PhaseTraffic, Priority: tt.priority, OnStop: func(context.Context) error {
+				stoppedWhileDraining = r.IsDraining()
+				return nil
+			}}))
+			require.NoError(t, lc.Append(r.LifecycleHook()))
+
+			require.NoError(t, lc.Stop(context.Background()))
+
+			assert.True(t, stoppedWhileDraining, "readiness must flip before servers stop")
+		})
+	}
+}